@@ -1,17 +1,55 @@
 package main
 
 import (
+	"context"
 	"github.com/evernetproto/evernet/internal/app/vertex"
 	"github.com/evernetproto/evernet/internal/pkg/env"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	vertex.NewServer(&vertex.ServerConfig{
-		Host:          env.GetOrDefault("HOST", "0.0.0.0"),
-		Port:          env.GetOrDefault("PORT", "9876"),
-		Vertex:        env.GetOrDefault("VERTEX", "localhost:9876"),
-		DataPath:      env.GetOrDefault("DATA_PATH", "data"),
-		StaticPath:    env.GetOrDefault("STATIC_PATH", "static"),
-		JwtSigningKey: env.GetOrDefault("JWT_SIGNING_KEY", "secret"),
-	}).Start()
+	server := vertex.NewServer(&vertex.ServerConfig{
+		Host:                       env.GetOrDefault("HOST", "0.0.0.0"),
+		Port:                       env.GetOrDefault("PORT", "9876"),
+		Vertex:                     env.GetOrDefault("VERTEX", "localhost:9876"),
+		DataPath:                   env.GetOrDefault("DATA_PATH", "data"),
+		StaticPath:                 env.GetOrDefault("STATIC_PATH", "static"),
+		JwtSigningKey:              env.GetOrDefault("JWT_SIGNING_KEY", "secret"),
+		FederationScheme:           env.GetOrDefault("FEDERATION_SCHEME", "https"),
+		FederationAllowInsecure:    env.GetBoolOrDefault("FEDERATION_ALLOW_INSECURE", false),
+		DeliveryWorkers:            env.GetIntOrDefault("DELIVERY_WORKERS", 4),
+		DeliveryMaxAttempts:        env.GetIntOrDefault("DELIVERY_MAX_ATTEMPTS", 10),
+		RetentionInterval:          env.GetIntOrDefault("RETENTION_INTERVAL", 300),
+		IdempotencyWindow:          env.GetIntOrDefault("IDEMPOTENCY_WINDOW", 86400),
+		IdempotencyLease:           env.GetIntOrDefault("IDEMPOTENCY_LEASE", 30),
+		WebhookWorkers:             env.GetIntOrDefault("WEBHOOK_WORKERS", 4),
+		WebhookMaxAttempts:         env.GetIntOrDefault("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookAllowedHosts:        env.GetListOrDefault("WEBHOOK_ALLOWED_HOSTS", nil),
+		SessionAllowedOrigins:      env.GetListOrDefault("SESSION_ALLOWED_ORIGINS", nil),
+		RemoteNodeCacheTTL:         env.GetIntOrDefault("REMOTE_NODE_CACHE_TTL", 300),
+		RemoteNodeMaxStale:         env.GetIntOrDefault("REMOTE_NODE_MAX_STALE", 86400),
+		FederationInsecureVertices: env.GetListOrDefault("FEDERATION_INSECURE_VERTICES", nil),
+		FederationCAFile:           env.GetOrDefault("FEDERATION_CA_FILE", ""),
+		FederationClientCertFile:   env.GetOrDefault("FEDERATION_CLIENT_CERT_FILE", ""),
+		FederationClientKeyFile:    env.GetOrDefault("FEDERATION_CLIENT_KEY_FILE", ""),
+		FederationTimeout:          env.GetIntOrDefault("FEDERATION_TIMEOUT", 5),
+		FederationPeerTimeouts:     env.GetIntMapOrDefault("FEDERATION_PEER_TIMEOUTS", nil),
+		FederationRequireSigned:    env.GetBoolOrDefault("FEDERATION_REQUIRE_SIGNED", false),
+	})
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_ = server.Stop(ctx)
+	}()
+
+	server.Start()
 }
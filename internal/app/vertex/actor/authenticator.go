@@ -2,11 +2,18 @@ package actor
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/discovery"
 	"github.com/evernetproto/evernet/internal/app/vertex/node"
+	"github.com/evernetproto/evernet/internal/app/vertex/policy"
 	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"slices"
 	"strings"
 	"time"
 )
@@ -25,12 +32,15 @@ type AuthenticatedActor struct {
 
 type Authenticator struct {
 	vertex            string
+	requireSigned     bool
 	nodeManager       *node.Manager
 	remoteNodeManager *node.RemoteManager
+	discoveryManager  *discovery.RemoteManager
+	policyManager     *policy.Manager
 }
 
-func NewAuthenticator(vertex string, nodeManager *node.Manager, remoteNodeManager *node.RemoteManager) *Authenticator {
-	return &Authenticator{vertex: vertex, nodeManager: nodeManager, remoteNodeManager: remoteNodeManager}
+func NewAuthenticator(vertex string, requireSigned bool, nodeManager *node.Manager, remoteNodeManager *node.RemoteManager, discoveryManager *discovery.RemoteManager, policyManager *policy.Manager) *Authenticator {
+	return &Authenticator{vertex: vertex, requireSigned: requireSigned, nodeManager: nodeManager, remoteNodeManager: remoteNodeManager, discoveryManager: discoveryManager, policyManager: policyManager}
 }
 
 const (
@@ -67,67 +77,88 @@ func (a *Authenticator) GenerateToken(identifier string, node *node.Node, target
 }
 
 func (a *Authenticator) ValidateContext(ctx context.Context, c *gin.Context) (*AuthenticatedActor, error) {
+	authenticatedActor, err := a.ValidateFederatedContext(ctx, c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if authenticatedActor.TargetNodeIdentifier == "" {
+		return nil, fmt.Errorf("invalid token audience")
+	}
+
+	return authenticatedActor, nil
+}
+
+func (a *Authenticator) ValidateFederatedContext(ctx context.Context, c *gin.Context) (*AuthenticatedActor, error) {
 	tokenType, token, err := api.ExtractToken(c)
 
 	if err != nil {
 		return nil, err
 	}
 
-	switch tokenType {
-	case BearerToken:
-		return a.validateBearerToken(ctx, token)
-	default:
+	if tokenType != BearerToken {
 		return nil, fmt.Errorf("invalid token type")
 	}
-}
 
-func (a *Authenticator) validateBearerToken(ctx context.Context, tokenString string) (*AuthenticatedActor, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		claims, ok := token.Claims.(jwt.MapClaims)
+	authenticatedActor, err := a.validateBearerToken(ctx, token)
 
-		if !ok {
-			return nil, fmt.Errorf("invalid token claims")
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		issuer, ok := claims["iss"]
+	if authenticatedActor.TargetNodeIdentifier == "" && authenticatedActor.TargetVertex != a.vertex {
+		return nil, fmt.Errorf("invalid token audience")
+	}
 
-		if !ok {
-			return nil, fmt.Errorf("invalid token issuer")
-		}
+	if authenticatedActor.SourceVertex == a.vertex && !federation.IsSigned(c.Request) {
+		return authenticatedActor, nil
+	}
 
-		issuerString, ok := issuer.(string)
+	vertex, err := a.validateSignature(ctx, c, authenticatedActor.SourceVertex)
 
-		if !ok {
-			return nil, fmt.Errorf("invalid token issuer")
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		issuerComponents := strings.Split(issuerString, "/")
+	if vertex != "" && authenticatedActor.SourceVertex != vertex {
+		return nil, fmt.Errorf("request signed by vertex %s for an actor of %s", vertex, authenticatedActor.SourceVertex)
+	}
 
-		if len(issuerComponents) != 2 {
-			return nil, fmt.Errorf("invalid token issuer")
+	return authenticatedActor, nil
+}
+
+func (a *Authenticator) validateSignature(ctx context.Context, c *gin.Context, sourceVertex string) (string, error) {
+	if !federation.IsSigned(c.Request) {
+		if a.requireSigned {
+			return "", fmt.Errorf("federated request is not signed")
 		}
 
-		sourceVertex := issuerComponents[0]
-		sourceNodeIdentifier := issuerComponents[1]
+		if a.discoveryManager.HasPublishedKey(ctx, sourceVertex) {
+			zap.L().Warn("rejected unsigned federated request", zap.String("vertex", sourceVertex), zap.String("path", c.Request.URL.Path))
+			return "", fmt.Errorf("federated request from %s is not signed", sourceVertex)
+		}
 
-		if sourceVertex == a.vertex {
-			sourceNode, err := a.nodeManager.Get(ctx, sourceNodeIdentifier)
+		zap.L().Warn("accepted unsigned federated request", zap.String("vertex", sourceVertex), zap.String("path", c.Request.URL.Path))
 
-			if err != nil {
-				return nil, err
-			}
+		return "", nil
+	}
 
-			return sourceNode.GetSigningPublicKey()
-		} else {
-			sourceNode, err := a.remoteNodeManager.Get(ctx, sourceVertex, sourceNodeIdentifier)
+	return federation.VerifyRequest(c.Request, a.vertex, a.vertexKey(ctx))
+}
 
-			if err != nil {
-				return nil, err
-			}
+func (a *Authenticator) validateBearerToken(ctx context.Context, tokenString string) (*AuthenticatedActor, error) {
+	token, err := jwt.Parse(tokenString, a.signingKey(ctx, false))
 
-			return sourceNode.GetSigningPublicKey()
-		}
-	})
+	if errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		token, err = jwt.Parse(tokenString, a.signingKey(ctx, true))
+	}
+
+	var blockedError *policy.BlockedError
+
+	if errors.As(err, &blockedError) {
+		return nil, blockedError
+	}
 
 	if err != nil {
 		return nil, err
@@ -197,12 +228,16 @@ func (a *Authenticator) validateBearerToken(ctx context.Context, tokenString str
 
 		audienceComponents := strings.Split(targetNodeAddress, "/")
 
-		if len(audienceComponents) != 2 {
+		if len(audienceComponents) > 2 || slices.Contains(audienceComponents, "") {
 			return nil, fmt.Errorf("invalid token audience")
 		}
 
 		targetVertex := audienceComponents[0]
-		targetNodeIdentifier := audienceComponents[1]
+		targetNodeIdentifier := ""
+
+		if len(audienceComponents) == 2 {
+			targetNodeIdentifier = audienceComponents[1]
+		}
 
 		return &AuthenticatedActor{
 			Identifier:           identifierString,
@@ -219,3 +254,72 @@ func (a *Authenticator) validateBearerToken(ctx context.Context, tokenString str
 		return nil, fmt.Errorf("invalid access token")
 	}
 }
+
+func (a *Authenticator) signingKey(ctx context.Context, refresh bool) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		claims, ok := token.Claims.(jwt.MapClaims)
+
+		if !ok {
+			return nil, fmt.Errorf("invalid token claims")
+		}
+
+		issuer, ok := claims["iss"]
+
+		if !ok {
+			return nil, fmt.Errorf("invalid token issuer")
+		}
+
+		issuerString, ok := issuer.(string)
+
+		if !ok {
+			return nil, fmt.Errorf("invalid token issuer")
+		}
+
+		issuerComponents := strings.Split(issuerString, "/")
+
+		if len(issuerComponents) != 2 {
+			return nil, fmt.Errorf("invalid token issuer")
+		}
+
+		sourceVertex := issuerComponents[0]
+		sourceNodeIdentifier := issuerComponents[1]
+
+		if sourceVertex == a.vertex {
+			sourceNode, err := a.nodeManager.Get(ctx, sourceNodeIdentifier)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return sourceNode.GetSigningPublicKey()
+		} else {
+			err := a.policyManager.Check(ctx, sourceVertex, sourceNodeIdentifier)
+
+			if err != nil {
+				return nil, err
+			}
+
+			sourceNode, err := a.getRemoteNode(ctx, sourceVertex, sourceNodeIdentifier, refresh)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return sourceNode.GetSigningPublicKey()
+		}
+	}
+}
+
+func (a *Authenticator) vertexKey(ctx context.Context) federation.SignatureKeyFunc {
+	return func(vertex string) (ed25519.PublicKey, error) {
+		return a.discoveryManager.PublicKey(ctx, vertex)
+	}
+}
+
+func (a *Authenticator) getRemoteNode(ctx context.Context, vertex string, identifier string, refresh bool) (*node.Node, error) {
+	if refresh {
+		return a.remoteNodeManager.Refresh(ctx, vertex, identifier)
+	}
+
+	return a.remoteNodeManager.Get(ctx, vertex, identifier)
+}
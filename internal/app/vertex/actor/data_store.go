@@ -15,13 +15,14 @@ func NewDataStore(db *sql.DB) *DataStore {
 
 func (d *DataStore) Insert(ctx context.Context, actor *Actor) (*Actor, error) {
 	_, err := d.db.ExecContext(ctx,
-		"INSERT INTO actors (identifier, display_name, type, password, node_identifier, creator, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO actors (identifier, display_name, type, password, node_identifier, creator, encryption_public_key, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		actor.Identifier,
 		actor.DisplayName,
 		actor.Type,
 		actor.Password,
 		actor.NodeIdentifier,
 		actor.Creator,
+		actor.EncryptionPublicKey,
 		actor.CreatedAt,
 		actor.UpdatedAt)
 
@@ -36,9 +37,9 @@ func (d *DataStore) FindByIdentifierAndNodeIdentifier(ctx context.Context, ident
 	var actor Actor
 
 	err := d.db.QueryRowContext(ctx,
-		"SELECT identifier, display_name, type, password, node_identifier, creator, created_at, updated_at FROM actors WHERE identifier = ? AND node_identifier = ?",
+		"SELECT identifier, display_name, type, password, node_identifier, creator, encryption_public_key, created_at, updated_at FROM actors WHERE identifier = ? AND node_identifier = ?",
 		identifier, nodeIdentifier).
-		Scan(&actor.Identifier, &actor.DisplayName, &actor.Type, &actor.Password, &actor.NodeIdentifier, &actor.Creator, &actor.CreatedAt, &actor.UpdatedAt)
+		Scan(&actor.Identifier, &actor.DisplayName, &actor.Type, &actor.Password, &actor.NodeIdentifier, &actor.Creator, &actor.EncryptionPublicKey, &actor.CreatedAt, &actor.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -110,6 +111,27 @@ func (d *DataStore) UpdateTypeByIdentifierAndNodeIdentifier(ctx context.Context,
 	return nil
 }
 
+func (d *DataStore) UpdateEncryptionPublicKeyByIdentifierAndNodeIdentifier(ctx context.Context, encryptionPublicKey string, identifier string, nodeIdentifier string) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE actors SET encryption_public_key = ? WHERE identifier = ? AND node_identifier = ?",
+		encryptionPublicKey, identifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 func (d *DataStore) DeleteByIdentifierAndNodeIdentifier(ctx context.Context, identifier string, nodeIdentifier string) error {
 	result, err := d.db.ExecContext(ctx,
 		"DELETE FROM actors WHERE identifier = ? AND node_identifier = ?",
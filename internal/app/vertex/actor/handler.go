@@ -2,6 +2,7 @@ package actor
 
 import (
 	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/idempotency"
 	"github.com/evernetproto/evernet/internal/pkg/api"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -9,16 +10,18 @@ import (
 )
 
 type Handler struct {
-	router        *gin.Engine
-	authenticator *Authenticator
-	manager       *Manager
+	router             *gin.Engine
+	authenticator      *Authenticator
+	manager            *Manager
+	idempotencyManager *idempotency.Manager
 }
 
-func NewHandler(router *gin.Engine, authenticator *Authenticator, manager *Manager) *Handler {
+func NewHandler(router *gin.Engine, authenticator *Authenticator, manager *Manager, idempotencyManager *idempotency.Manager) *Handler {
 	return &Handler{
-		router:        router,
-		authenticator: authenticator,
-		manager:       manager,
+		router:             router,
+		authenticator:      authenticator,
+		manager:            manager,
+		idempotencyManager: idempotencyManager,
 	}
 }
 
@@ -27,6 +30,10 @@ func (h *Handler) Register() {
 		ctx, cancel := context.WithTimeout(c, 5*time.Second)
 		defer cancel()
 
+		if h.idempotencyManager.ReplayAnonymous(ctx, c) {
+			return
+		}
+
 		var request SignUpRequest
 		if err := c.ShouldBindJSON(&request); err != nil {
 			api.Error(c, http.StatusBadRequest, err)
@@ -187,6 +194,81 @@ func (h *Handler) Register() {
 		api.Success(c, http.StatusOK, "actor type updated successfully")
 	})
 
+	h.router.PUT("/api/v1/actors/current/encryption-key", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		if !authenticatedActor.IsLocal {
+			api.ErrorMessage(c, http.StatusForbidden, "not allowed")
+			return
+		}
+
+		var request EncryptionKeyUpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.UpdateEncryptionKey(ctx, authenticatedActor.Identifier, &request, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "encryption key updated successfully")
+	})
+
+	h.router.GET("/api/v1/nodes/:nodeIdentifier/actors/:actorIdentifier/encryption-key", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		nodeIdentifier := c.Param("nodeIdentifier")
+		identifier := c.Param("actorIdentifier")
+
+		encryptionKey, err := h.manager.GetEncryptionKey(ctx, identifier, nodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusNotFound, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, encryptionKey)
+	})
+
+	h.router.GET("/api/v1/actors/encryption-keys", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 10*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		actorAddress := c.Query("address")
+
+		if actorAddress == "" {
+			api.ErrorMessage(c, http.StatusBadRequest, "address is required")
+			return
+		}
+
+		encryptionKey, err := h.manager.ResolveEncryptionKey(ctx, actorAddress)
+
+		if err != nil {
+			api.Error(c, http.StatusNotFound, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, encryptionKey)
+	})
+
 	h.router.DELETE("/api/v1/actors/current", func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c, 5*time.Second)
 		defer cancel()
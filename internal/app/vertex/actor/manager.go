@@ -6,18 +6,22 @@ import (
 	"errors"
 	"fmt"
 	"github.com/evernetproto/evernet/internal/app/vertex/node"
+	"github.com/evernetproto/evernet/internal/pkg/keys"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"time"
 )
 
 type Manager struct {
+	vertex        string
 	dataStore     *DataStore
 	nodeManager   *node.Manager
 	authenticator *Authenticator
+	remoteManager *RemoteManager
 }
 
-func NewManager(dataStore *DataStore, nodeManager *node.Manager, authenticator *Authenticator) *Manager {
-	return &Manager{dataStore: dataStore, nodeManager: nodeManager, authenticator: authenticator}
+func NewManager(vertex string, dataStore *DataStore, nodeManager *node.Manager, authenticator *Authenticator, remoteManager *RemoteManager) *Manager {
+	return &Manager{vertex: vertex, dataStore: dataStore, nodeManager: nodeManager, authenticator: authenticator, remoteManager: remoteManager}
 }
 
 func (m *Manager) SignUp(ctx context.Context, nodeIdentifier string, request *SignUpRequest) (*Actor, error) {
@@ -138,6 +142,57 @@ func (m *Manager) UpdateType(ctx context.Context, identifier string, request *Ty
 	return err
 }
 
+func (m *Manager) UpdateEncryptionKey(ctx context.Context, identifier string, request *EncryptionKeyUpdateRequest, nodeIdentifier string) error {
+	_, err := keys.ConvertX25519PublicKeyFromString(request.PublicKey)
+
+	if err != nil {
+		return fmt.Errorf("invalid x25519 public key")
+	}
+
+	err = m.dataStore.UpdateEncryptionPublicKeyByIdentifierAndNodeIdentifier(ctx, request.PublicKey, identifier, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("actor %s not found", identifier)
+	}
+
+	return err
+}
+
+func (m *Manager) GetEncryptionKey(ctx context.Context, identifier string, nodeIdentifier string) (*EncryptionKeyResponse, error) {
+	actor, err := m.Get(ctx, identifier, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.EncryptionPublicKey == "" {
+		return nil, fmt.Errorf("actor %s has no encryption key", identifier)
+	}
+
+	return &EncryptionKeyResponse{
+		ActorAddress: fmt.Sprintf("%s/%s/%s", m.vertex, nodeIdentifier, identifier),
+		PublicKey:    actor.EncryptionPublicKey,
+	}, nil
+}
+
+func (m *Manager) ResolveEncryptionKey(ctx context.Context, actorAddress string) (*EncryptionKeyResponse, error) {
+	addressComponents := strings.Split(actorAddress, "/")
+
+	if len(addressComponents) != 3 {
+		return nil, fmt.Errorf("invalid actor address %s", actorAddress)
+	}
+
+	actorVertex := addressComponents[0]
+	nodeIdentifier := addressComponents[1]
+	identifier := addressComponents[2]
+
+	if actorVertex == m.vertex {
+		return m.GetEncryptionKey(ctx, identifier, nodeIdentifier)
+	}
+
+	return m.remoteManager.GetEncryptionKey(ctx, actorVertex, nodeIdentifier, identifier)
+}
+
 func (m *Manager) Delete(ctx context.Context, identifier string, nodeIdentifier string) error {
 	err := m.dataStore.DeleteByIdentifierAndNodeIdentifier(ctx, identifier, nodeIdentifier)
 
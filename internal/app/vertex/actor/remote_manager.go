@@ -0,0 +1,34 @@
+package actor
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/discovery"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
+)
+
+type RemoteManager struct {
+	federationClient *federation.Client
+	discoveryManager *discovery.RemoteManager
+}
+
+func NewRemoteManager(federationClient *federation.Client, discoveryManager *discovery.RemoteManager) *RemoteManager {
+	return &RemoteManager{federationClient: federationClient, discoveryManager: discoveryManager}
+}
+
+func (m *RemoteManager) GetEncryptionKey(ctx context.Context, actorVertex string, nodeIdentifier string, identifier string) (*EncryptionKeyResponse, error) {
+	url, err := m.discoveryManager.URL(ctx, actorVertex, discovery.EndpointActorEncryptionKey, nodeIdentifier, identifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var encryptionKey EncryptionKeyResponse
+
+	err = m.federationClient.Get(ctx, url, "", &encryptionKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptionKey, nil
+}
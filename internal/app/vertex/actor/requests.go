@@ -24,3 +24,7 @@ type DisplayNameUpdateRequest struct {
 type TypeUpdateRequest struct {
 	Type string `json:"type" binding:"required"`
 }
+
+type EncryptionKeyUpdateRequest struct {
+	PublicKey string `json:"public_key" binding:"required"`
+}
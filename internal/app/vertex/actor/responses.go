@@ -3,3 +3,8 @@ package actor
 type TokenResponse struct {
 	Token string `json:"token"`
 }
+
+type EncryptionKeyResponse struct {
+	ActorAddress string `json:"actor_address"`
+	PublicKey    string `json:"public_key"`
+}
@@ -3,6 +3,7 @@ package admin
 import (
 	"context"
 	"database/sql"
+	"github.com/evernetproto/evernet/internal/pkg/api"
 	"go.uber.org/zap"
 )
 
@@ -40,11 +41,12 @@ func (d *DataStore) FindByIdentifier(ctx context.Context, identifier string) (*A
 	return &a, nil
 }
 
-func (d *DataStore) FindAll(ctx context.Context, page int64, size int64) ([]*Admin, error) {
+func (d *DataStore) FindAll(ctx context.Context, pagination *api.Pagination) ([]*Admin, error) {
 	var admins []*Admin
+	createdAt, identifier := pagination.Bounds(false)
 	rows, err := d.db.QueryContext(ctx,
-		"SELECT identifier, password, creator, created_at, updated_at FROM admins LIMIT ? OFFSET ?",
-		size, page*size)
+		"SELECT identifier, password, creator, created_at, updated_at FROM admins WHERE (created_at, identifier) > (?, ?) ORDER BY created_at, identifier LIMIT ? OFFSET ?",
+		createdAt, identifier, pagination.Size, pagination.Offset())
 
 	if err != nil {
 		return nil, err
@@ -189,15 +189,21 @@ func (h *Handler) Register() {
 			return
 		}
 
-		page, size := api.Page(c)
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
 
-		admins, err := h.manager.List(ctx, page, size)
+		admins, err := h.manager.List(ctx, pagination)
 		if err != nil {
 			api.Error(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, admins)
+		api.Paginated(c, http.StatusOK, pagination, admins, func(admin *Admin) *api.Cursor {
+			return &api.Cursor{CreatedAt: admin.CreatedAt, Identifier: admin.Identifier}
+		})
 	})
 
 	h.router.PUT("/api/v1/admins/:identifier/password", func(c *gin.Context) {
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/api"
 	"github.com/sethvargo/go-password/password"
 	"golang.org/x/crypto/bcrypt"
 	"time"
@@ -162,8 +163,8 @@ func (m *Manager) Delete(ctx context.Context, identifier string) error {
 	return err
 }
 
-func (m *Manager) List(ctx context.Context, page int64, size int64) ([]*Admin, error) {
-	return m.dataStore.FindAll(ctx, page, size)
+func (m *Manager) List(ctx context.Context, pagination *api.Pagination) ([]*Admin, error) {
+	return m.dataStore.FindAll(ctx, pagination)
 }
 
 func (m *Manager) ResetPassword(ctx context.Context, identifier string) (*PasswordResponse, error) {
@@ -19,6 +19,8 @@ func MigrateDatabase(path string, databaseName string) *sql.DB {
 		zap.L().Fatal("failed to open sqlite database", zap.Error(err))
 	}
 
+	requireSearch(db)
+
 	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
 
 	if err != nil {
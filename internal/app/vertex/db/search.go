@@ -0,0 +1,15 @@
+package db
+
+import (
+	"database/sql"
+	"go.uber.org/zap"
+)
+
+func requireSearch(db *sql.DB) {
+	var fts5 bool
+	err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5)
+
+	if err != nil || !fts5 {
+		zap.L().Fatal("sqlite is built without fts5 support, build with -tags sqlite_fts5", zap.Error(err))
+	}
+}
@@ -0,0 +1,59 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DataStore struct {
+	db *sql.DB
+}
+
+func NewDataStore(db *sql.DB) *DataStore {
+	return &DataStore{db: db}
+}
+
+func (d *DataStore) InsertKey(ctx context.Context, key *VertexKey) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO vertex_keys (vertex, public_key, pinned_at) VALUES (?, ?, ?) ON CONFLICT (vertex) DO NOTHING",
+		key.Vertex,
+		key.PublicKey,
+		key.PinnedAt)
+
+	return err
+}
+
+func (d *DataStore) FindKeyByVertex(ctx context.Context, vertex string) (*VertexKey, error) {
+	var key VertexKey
+	err := d.db.QueryRowContext(ctx,
+		"SELECT vertex, public_key, pinned_at FROM vertex_keys WHERE vertex = ?",
+		vertex).
+		Scan(&key.Vertex, &key.PublicKey, &key.PinnedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (d *DataStore) DeleteKeyByVertex(ctx context.Context, vertex string) error {
+	result, err := d.db.ExecContext(ctx,
+		"DELETE FROM vertex_keys WHERE vertex = ?",
+		vertex)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package discovery
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/admin"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const documentCacheControl = "public, max-age=3600"
+
+type Handler struct {
+	router        *gin.Engine
+	authenticator *admin.Authenticator
+	manager       *Manager
+	remoteManager *RemoteManager
+}
+
+func NewHandler(router *gin.Engine, authenticator *admin.Authenticator, manager *Manager, remoteManager *RemoteManager) *Handler {
+	return &Handler{router: router, authenticator: authenticator, manager: manager, remoteManager: remoteManager}
+}
+
+func (h *Handler) Register() {
+
+	h.router.GET(WellKnownPath, func(c *gin.Context) {
+		document, err := h.manager.Get()
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.Header("Cache-Control", documentCacheControl)
+		c.JSON(http.StatusOK, document)
+	})
+
+	h.router.GET("/api/v1/federation/vertices/:vertex/key", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		key, err := h.remoteManager.GetKey(ctx, c.Param("vertex"))
+
+		if err != nil {
+			api.Error(c, http.StatusNotFound, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, key)
+	})
+
+	h.router.DELETE("/api/v1/federation/vertices/:vertex/key", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		err = h.remoteManager.Unpin(ctx, c.Param("vertex"))
+
+		if err != nil {
+			api.Error(c, http.StatusNotFound, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "vertex key unpinned successfully")
+	})
+}
@@ -0,0 +1,42 @@
+package discovery
+
+import (
+	"crypto/ed25519"
+	"github.com/evernetproto/evernet/internal/pkg/keys"
+)
+
+type Manager struct {
+	vertex     string
+	scheme     string
+	signingKey ed25519.PrivateKey
+}
+
+func NewManager(vertex string, scheme string, signingKey ed25519.PrivateKey) *Manager {
+	return &Manager{vertex: vertex, scheme: scheme, signingKey: signingKey}
+}
+
+func (m *Manager) Get() (*Document, error) {
+	endpoints := make(map[string]string, len(defaultEndpoints))
+
+	for name, path := range defaultEndpoints {
+		endpoints[name] = m.scheme + "://" + m.vertex + path
+	}
+
+	document := &Document{
+		Vertex:          m.vertex,
+		ProtocolVersion: ProtocolVersion,
+		Features:        []string{FeatureMessaging, FeatureE2E, FeatureSSE},
+		Endpoints:       endpoints,
+		PublicKey:       keys.ConvertED25519PublicKeyToString(m.signingKey.Public().(ed25519.PublicKey)),
+	}
+
+	data, err := document.Canonicalize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	document.Signature = keys.SignED25519(m.signingKey, data)
+
+	return document, nil
+}
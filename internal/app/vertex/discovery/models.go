@@ -0,0 +1,157 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/keys"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	WellKnownPath   = "/.well-known/evernet"
+	ProtocolVersion = "1"
+)
+
+const (
+	FeatureMessaging = "messaging"
+	FeatureE2E       = "e2e"
+	FeatureSSE       = "sse"
+)
+
+const (
+	EndpointNode               = "node"
+	EndpointActorEncryptionKey = "actor_encryption_key"
+	EndpointDeliveries         = "deliveries"
+	EndpointDeliveryBatches    = "delivery_batches"
+	EndpointDeliveryRevisions  = "delivery_revisions"
+	EndpointReceipts           = "receipts"
+	EndpointBlob               = "blob"
+)
+
+var defaultEndpoints = map[string]string{
+	EndpointNode:               "/api/v1/nodes/{node}",
+	EndpointActorEncryptionKey: "/api/v1/nodes/{node}/actors/{actor}/encryption-key",
+	EndpointDeliveries:         "/api/v1/messaging/deliveries",
+	EndpointDeliveryBatches:    "/api/v1/messaging/deliveries/batch",
+	EndpointDeliveryRevisions:  "/api/v1/messaging/deliveries/revisions",
+	EndpointReceipts:           "/api/v1/messaging/receipts",
+	EndpointBlob:               "/api/v1/messaging/blobs/{hash}",
+}
+
+type VertexKey struct {
+	Vertex    string `json:"vertex" db:"vertex"`
+	PublicKey string `json:"public_key" db:"public_key"`
+	PinnedAt  int64  `json:"pinned_at" db:"pinned_at"`
+}
+
+type Document struct {
+	Vertex          string            `json:"vertex"`
+	ProtocolVersion string            `json:"protocol_version"`
+	Features        []string          `json:"features"`
+	Endpoints       map[string]string `json:"endpoints"`
+	PublicKey       string            `json:"public_key"`
+	Signature       string            `json:"signature,omitempty"`
+}
+
+func (d *Document) Canonicalize() ([]byte, error) {
+	unsigned := *d
+	unsigned.Signature = ""
+
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(&unsigned); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+func (d *Document) Verify(vertex string) error {
+	if d.Vertex != vertex {
+		return fmt.Errorf("discovery document for %s was served by %s", d.Vertex, vertex)
+	}
+
+	publicKey, err := keys.ConvertED25519PublicKeyFromString(d.PublicKey)
+
+	if err != nil {
+		return fmt.Errorf("invalid public key in discovery document for %s", vertex)
+	}
+
+	data, err := d.Canonicalize()
+
+	if err != nil {
+		return err
+	}
+
+	if !keys.VerifyED25519(publicKey, data, d.Signature) {
+		return fmt.Errorf("invalid signature on discovery document for %s", vertex)
+	}
+
+	for name, endpoint := range d.Endpoints {
+		if !isValidEndpoint(endpoint, vertex) {
+			return fmt.Errorf("invalid %s endpoint in discovery document for %s", name, vertex)
+		}
+	}
+
+	return nil
+}
+
+func isValidEndpoint(endpoint string, vertex string) bool {
+	target, err := url.Parse(endpoint)
+
+	if err != nil || target.User != nil {
+		return false
+	}
+
+	if isRelativeEndpoint(target) {
+		return true
+	}
+
+	return (target.Scheme == "http" || target.Scheme == "https") && strings.EqualFold(target.Host, vertex)
+}
+
+func isRelativeEndpoint(target *url.URL) bool {
+	return target.Scheme == "" && target.Host == "" && strings.HasPrefix(target.Path, "/")
+}
+
+func (d *Document) HasFeature(feature string) bool {
+	return slices.Contains(d.Features, feature)
+}
+
+func expand(template string, params []string) (string, error) {
+	var builder strings.Builder
+
+	for {
+		start := strings.Index(template, "{")
+
+		if start < 0 {
+			break
+		}
+
+		end := strings.Index(template[start:], "}")
+
+		if end < 0 {
+			return "", fmt.Errorf("invalid endpoint template %s", template)
+		}
+
+		if len(params) == 0 {
+			return "", fmt.Errorf("missing parameter %s", template[start:start+end+1])
+		}
+
+		builder.WriteString(template[:start])
+		builder.WriteString(url.PathEscape(params[0]))
+
+		template = template[start+end+1:]
+		params = params[1:]
+	}
+
+	builder.WriteString(template)
+
+	return builder.String(), nil
+}
@@ -0,0 +1,53 @@
+package discovery
+
+import (
+	"github.com/evernetproto/evernet/internal/pkg/keys"
+	"testing"
+)
+
+func TestDocumentVerifyEndpoints(t *testing.T) {
+	_, privateKey, err := keys.GenerateED25519KeyPair()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{"/api/v1/messaging/deliveries", true},
+		{"https://vertex.example/api/v1/messaging/deliveries", true},
+		{"https://VERTEX.example/api/v1/messaging/deliveries", true},
+		{"https://attacker.example/api/v1/messaging/deliveries", false},
+		{"https://vertex.example:8443/api/v1/messaging/deliveries", false},
+		{"https://user@vertex.example/api/v1/messaging/deliveries", false},
+		{"//attacker.example/api/v1/messaging/deliveries", false},
+		{"ftp://vertex.example/deliveries", false},
+		{"api/v1/messaging/deliveries", false},
+	}
+
+	for _, test := range tests {
+		document, err := NewManager("vertex.example", "https", privateKey).Get()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		document.Endpoints[EndpointDeliveries] = test.endpoint
+
+		data, err := document.Canonicalize()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		document.Signature = keys.SignED25519(privateKey, data)
+
+		err = document.Verify("vertex.example")
+
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid=%t, got %v", test.endpoint, test.valid, err)
+		}
+	}
+}
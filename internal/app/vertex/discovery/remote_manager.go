@@ -0,0 +1,204 @@
+package discovery
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/cache"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
+	"github.com/evernetproto/evernet/internal/pkg/keys"
+	"go.uber.org/zap"
+	"net/url"
+	"time"
+)
+
+const (
+	remoteDocumentDefaultTTL    = time.Hour
+	remoteDocumentMaxTTL        = 24 * time.Hour
+	remoteDocumentRetryInterval = 5 * time.Minute
+	remoteDocumentCacheSize     = 1000
+)
+
+type remoteDocumentEntry struct {
+	document  *Document
+	expiresAt time.Time
+}
+
+type RemoteManager struct {
+	federationClient *federation.Client
+	dataStore        *DataStore
+	cache            *cache.LRU[string, *remoteDocumentEntry]
+}
+
+func NewRemoteManager(federationClient *federation.Client, dataStore *DataStore) *RemoteManager {
+	return &RemoteManager{
+		federationClient: federationClient,
+		dataStore:        dataStore,
+		cache:            cache.NewLRU[string, *remoteDocumentEntry](remoteDocumentCacheSize),
+	}
+}
+
+func (m *RemoteManager) URL(ctx context.Context, vertex string, endpoint string, params ...string) (string, error) {
+	document := m.Get(ctx, vertex)
+
+	if document != nil {
+		if template, ok := document.Endpoints[endpoint]; ok {
+			expanded, err := expand(template, params)
+
+			if err != nil {
+				return "", err
+			}
+
+			if target, err := url.Parse(expanded); err == nil && isRelativeEndpoint(target) {
+				return m.federationClient.URL(vertex, expanded), nil
+			}
+
+			return expanded, nil
+		}
+	}
+
+	path, err := expand(defaultEndpoints[endpoint], params)
+
+	if err != nil {
+		return "", err
+	}
+
+	return m.federationClient.URL(vertex, path), nil
+}
+
+func (m *RemoteManager) Get(ctx context.Context, vertex string) *Document {
+	entry, ok := m.cache.Get(vertex)
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.document
+	}
+
+	document, ttl, err := m.fetch(ctx, vertex)
+
+	if err != nil {
+		zap.L().Debug("error fetching discovery document", zap.String("vertex", vertex), zap.Error(err))
+
+		var stale *Document
+
+		if ok {
+			stale = entry.document
+		}
+
+		m.store(vertex, stale, remoteDocumentRetryInterval)
+
+		return stale
+	}
+
+	m.store(vertex, document, ttl)
+
+	return document
+}
+
+func (m *RemoteManager) PublicKey(ctx context.Context, vertex string) (ed25519.PublicKey, error) {
+	document := m.Get(ctx, vertex)
+
+	if document == nil {
+		return nil, fmt.Errorf("unable to resolve public key of vertex %s", vertex)
+	}
+
+	return keys.ConvertED25519PublicKeyFromString(document.PublicKey)
+}
+
+func (m *RemoteManager) HasPublishedKey(ctx context.Context, vertex string) bool {
+	_, err := m.dataStore.FindKeyByVertex(ctx, vertex)
+
+	if err == nil {
+		return true
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		zap.L().Error("error finding pinned key", zap.String("vertex", vertex), zap.Error(err))
+		return true
+	}
+
+	document := m.Get(ctx, vertex)
+
+	return document != nil && document.PublicKey != ""
+}
+
+func (m *RemoteManager) fetch(ctx context.Context, vertex string) (*Document, time.Duration, error) {
+	var document Document
+
+	header, err := m.federationClient.GetWithHeader(ctx, m.federationClient.URL(vertex, WellKnownPath), "", &document)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = document.Verify(vertex)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = m.pin(ctx, vertex, document.PublicKey)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ttl, store := federation.CacheLifetime(header, remoteDocumentDefaultTTL, remoteDocumentMaxTTL)
+
+	if !store {
+		ttl = 0
+	}
+
+	return &document, ttl, nil
+}
+
+func (m *RemoteManager) GetKey(ctx context.Context, vertex string) (*VertexKey, error) {
+	key, err := m.dataStore.FindKeyByVertex(ctx, vertex)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no public key is pinned for vertex %s", vertex)
+	}
+
+	return key, err
+}
+
+func (m *RemoteManager) Unpin(ctx context.Context, vertex string) error {
+	err := m.dataStore.DeleteKeyByVertex(ctx, vertex)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no public key is pinned for vertex %s", vertex)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	m.cache.Remove(vertex)
+
+	return nil
+}
+
+func (m *RemoteManager) pin(ctx context.Context, vertex string, publicKey string) error {
+	err := m.dataStore.InsertKey(ctx, &VertexKey{Vertex: vertex, PublicKey: publicKey, PinnedAt: time.Now().UnixNano()})
+
+	if err != nil {
+		return err
+	}
+
+	key, err := m.dataStore.FindKeyByVertex(ctx, vertex)
+
+	if err != nil {
+		return err
+	}
+
+	if key.PublicKey != publicKey {
+		return fmt.Errorf("public key of vertex %s does not match the pinned key", vertex)
+	}
+
+	return nil
+}
+
+func (m *RemoteManager) store(vertex string, document *Document, ttl time.Duration) {
+	m.cache.Put(vertex, &remoteDocumentEntry{document: document, expiresAt: time.Now().Add(ttl)})
+}
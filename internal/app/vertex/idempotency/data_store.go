@@ -0,0 +1,107 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DataStore struct {
+	db *sql.DB
+}
+
+func NewDataStore(db *sql.DB) *DataStore {
+	return &DataStore{db: db}
+}
+
+func (d *DataStore) Insert(ctx context.Context, record *Record) (bool, error) {
+	result, err := d.db.ExecContext(ctx,
+		"INSERT INTO idempotency_keys (principal, key, fingerprint, status_code, content_type, body, completed, reserved_at, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (principal, key) DO NOTHING",
+		record.Principal,
+		record.Key,
+		record.Fingerprint,
+		record.StatusCode,
+		record.ContentType,
+		record.Body,
+		record.Completed,
+		record.ReservedAt,
+		record.CreatedAt,
+		record.ExpiresAt)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (d *DataStore) FindByPrincipalAndKey(ctx context.Context, principal string, key string) (*Record, error) {
+	var record Record
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT principal, key, fingerprint, status_code, content_type, body, completed, reserved_at, created_at, expires_at FROM idempotency_keys WHERE principal = ? AND key = ?",
+		principal, key).
+		Scan(
+			&record.Principal,
+			&record.Key,
+			&record.Fingerprint,
+			&record.StatusCode,
+			&record.ContentType,
+			&record.Body,
+			&record.Completed,
+			&record.ReservedAt,
+			&record.CreatedAt,
+			&record.ExpiresAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (d *DataStore) UpdateResponseByPrincipalAndKey(ctx context.Context, statusCode int, contentType string, body []byte, principal string, key string) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status_code = ?, content_type = ?, body = ?, completed = 1 WHERE principal = ? AND key = ?",
+		statusCode, contentType, body, principal, key)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *DataStore) DeleteByPrincipalAndKey(ctx context.Context, principal string, key string) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE principal = ? AND key = ?", principal, key)
+	return err
+}
+
+func (d *DataStore) DeleteByPrincipalAndKeyAndExpiresAtBeforeOrReservedAtBefore(ctx context.Context, principal string, key string, expiresAt int64, reservedAt int64) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE principal = ? AND key = ? AND (expires_at < ? OR (completed = 0 AND reserved_at < ?))", principal, key, expiresAt, reservedAt)
+	return err
+}
+
+func (d *DataStore) DeleteByExpiresAtBeforeOrReservedAtBefore(ctx context.Context, expiresAt int64, reservedAt int64) (int64, error) {
+	result, err := d.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < ? OR (completed = 0 AND reserved_at < ?)", expiresAt, reservedAt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
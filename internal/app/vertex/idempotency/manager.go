@@ -0,0 +1,266 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	maxKeyLength     = 255
+	purgeInterval    = time.Hour
+	completeTimeout  = 5 * time.Second
+	reservationValue = "idempotency_reservation"
+)
+
+type Manager struct {
+	window    time.Duration
+	lease     time.Duration
+	dataStore *DataStore
+}
+
+func NewManager(window time.Duration, lease time.Duration, dataStore *DataStore) *Manager {
+	return &Manager{window: window, lease: lease, dataStore: dataStore}
+}
+
+type reservation struct {
+	principal string
+	key       string
+	recorder  *recorder
+}
+
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *recorder) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
+
+func (m *Manager) Replay(ctx context.Context, c *gin.Context, principal string) bool {
+	key := c.GetHeader(api.IdempotencyKeyHeader)
+
+	if key == "" {
+		return false
+	}
+
+	if len(key) > maxKeyLength {
+		api.ErrorMessage(c, http.StatusBadRequest, fmt.Sprintf("idempotency key must be at most %d characters", maxKeyLength))
+		return true
+	}
+
+	fingerprint, err := m.fingerprint(c)
+
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return true
+	}
+
+	now := time.Now()
+
+	err = m.dataStore.DeleteByPrincipalAndKeyAndExpiresAtBeforeOrReservedAtBefore(ctx, principal, key, now.UnixNano(), now.Add(-m.lease).UnixNano())
+
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return true
+	}
+
+	reserved, err := m.dataStore.Insert(ctx, &Record{
+		Principal:   principal,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Body:        []byte{},
+		Completed:   false,
+		ReservedAt:  now.UnixNano(),
+		CreatedAt:   now.UnixNano(),
+		ExpiresAt:   now.Add(m.window).UnixNano(),
+	})
+
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return true
+	}
+
+	if reserved {
+		writer := &recorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Set(reservationValue, &reservation{principal: principal, key: key, recorder: writer})
+		return false
+	}
+
+	record, err := m.dataStore.FindByPrincipalAndKey(ctx, principal, key)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		api.ErrorMessage(c, http.StatusConflict, fmt.Sprintf("request with idempotency key %s is still in progress", key))
+		return true
+	}
+
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err)
+		return true
+	}
+
+	if record.Fingerprint != fingerprint {
+		api.ErrorMessage(c, http.StatusUnprocessableEntity, fmt.Sprintf("idempotency key %s was already used for a different request", key))
+		return true
+	}
+
+	if !record.Completed {
+		api.ErrorMessage(c, http.StatusConflict, fmt.Sprintf("request with idempotency key %s is still in progress", key))
+		return true
+	}
+
+	c.Header(api.IdempotentReplayedHeader, "true")
+	c.Data(record.StatusCode, record.ContentType, record.Body)
+	c.Abort()
+
+	return true
+}
+
+func (m *Manager) ReplayAnonymous(ctx context.Context, c *gin.Context) bool {
+	if c.GetHeader(api.IdempotencyKeyHeader) == "" {
+		return false
+	}
+
+	body, err := readBody(c)
+
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, err)
+		return true
+	}
+
+	hash := sha256.Sum256(body)
+
+	return m.Replay(ctx, c, "anonymous:"+hex.EncodeToString(hash[:]))
+}
+
+func (m *Manager) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+
+			if recovered == nil {
+				return
+			}
+
+			reservation, ok := reservationOf(c)
+
+			if ok {
+				m.release(reservation)
+			}
+
+			panic(recovered)
+		}()
+
+		c.Next()
+
+		reservation, ok := reservationOf(c)
+
+		if !ok {
+			return
+		}
+
+		statusCode := reservation.recorder.Status()
+
+		if statusCode >= http.StatusInternalServerError {
+			m.release(reservation)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), completeTimeout)
+		defer cancel()
+
+		err := m.dataStore.UpdateResponseByPrincipalAndKey(ctx, statusCode, reservation.recorder.Header().Get("Content-Type"), reservation.recorder.body.Bytes(), reservation.principal, reservation.key)
+
+		if err != nil {
+			zap.L().Error("error storing idempotent response", zap.String("key", reservation.key), zap.Error(err))
+		}
+	}
+}
+
+func (m *Manager) release(reservation *reservation) {
+	ctx, cancel := context.WithTimeout(context.Background(), completeTimeout)
+	defer cancel()
+
+	err := m.dataStore.DeleteByPrincipalAndKey(ctx, reservation.principal, reservation.key)
+
+	if err != nil {
+		zap.L().Error("error releasing idempotency key", zap.String("key", reservation.key), zap.Error(err))
+	}
+}
+
+func reservationOf(c *gin.Context) (*reservation, bool) {
+	value, ok := c.Get(reservationValue)
+
+	if !ok {
+		return nil, false
+	}
+
+	return value.(*reservation), true
+}
+
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		purged, err := m.dataStore.DeleteByExpiresAtBeforeOrReservedAtBefore(ctx, now.UnixNano(), now.Add(-m.lease).UnixNano())
+
+		if err != nil {
+			zap.L().Error("error purging idempotency keys", zap.Error(err))
+			continue
+		}
+
+		zap.L().Debug("purged idempotency keys", zap.Int64("count", purged))
+	}
+}
+
+func (m *Manager) fingerprint(c *gin.Context) (string, error) {
+	body, err := readBody(c)
+
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func readBody(c *gin.Context) ([]byte, error) {
+	body, err := io.ReadAll(c.Request.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
@@ -0,0 +1,242 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/db"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	err := os.Chdir("../../../..")
+
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+type testServer struct {
+	t          *testing.T
+	router     *gin.Engine
+	dataStore  *DataStore
+	executions int
+	status     int
+	panics     bool
+}
+
+func newTestServer(t *testing.T, window time.Duration, lease time.Duration) *testServer {
+	database := db.MigrateDatabase(filepath.Join(t.TempDir(), "vertex.db"), "vertex")
+
+	t.Cleanup(func() {
+		_ = database.Close()
+	})
+
+	server := &testServer{t: t, router: gin.New(), dataStore: NewDataStore(database), status: http.StatusCreated}
+	manager := NewManager(window, lease, server.dataStore)
+
+	server.router.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	server.router.Use(manager.Middleware())
+
+	execute := func(c *gin.Context) {
+		server.executions++
+
+		if server.panics {
+			panic("handler failed")
+		}
+
+		c.JSON(server.status, gin.H{"execution": server.executions})
+	}
+
+	handler := func(c *gin.Context) {
+		if manager.Replay(c, c, c.GetHeader("Principal")) {
+			return
+		}
+
+		execute(c)
+	}
+
+	server.router.POST("/messages", handler)
+	server.router.POST("/deliveries", handler)
+	server.router.POST("/signup", func(c *gin.Context) {
+		if manager.ReplayAnonymous(c, c) {
+			return
+		}
+
+		execute(c)
+	})
+
+	return server
+}
+
+func (s *testServer) do(path string, principal string, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Principal", principal)
+
+	if key != "" {
+		req.Header.Set(api.IdempotencyKeyHeader, key)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func (s *testServer) expect(recorder *httptest.ResponseRecorder, status int, body string, replayed bool) {
+	s.t.Helper()
+
+	if recorder.Code != status {
+		s.t.Fatalf("expected status %d, got %d: %s", status, recorder.Code, recorder.Body.String())
+	}
+
+	if body != "" && recorder.Body.String() != body {
+		s.t.Fatalf("expected body %s, got %s", body, recorder.Body.String())
+	}
+
+	if got := recorder.Header().Get(api.IdempotentReplayedHeader) == "true"; got != replayed {
+		s.t.Fatalf("expected replayed %v, got %v", replayed, got)
+	}
+}
+
+func fingerprint(method string, path string, body string) string {
+	digest := sha256.Sum256([]byte(method + " " + path + "\n" + body))
+	return hex.EncodeToString(digest[:])
+}
+
+func TestReplay(t *testing.T) {
+	server := newTestServer(t, time.Hour, time.Minute)
+
+	server.expect(server.do("/messages", "alice", "", `{"content":"a"}`), http.StatusCreated, `{"execution":1}`, false)
+	server.expect(server.do("/messages", "alice", "", `{"content":"a"}`), http.StatusCreated, `{"execution":2}`, false)
+
+	server.expect(server.do("/messages", "alice", "key", `{"content":"a"}`), http.StatusCreated, `{"execution":3}`, false)
+	server.expect(server.do("/messages", "alice", "key", `{"content":"a"}`), http.StatusCreated, `{"execution":3}`, true)
+
+	server.expect(server.do("/messages", "bob", "key", `{"content":"a"}`), http.StatusCreated, `{"execution":4}`, false)
+
+	if server.executions != 4 {
+		t.Fatalf("expected 4 executions, got %d", server.executions)
+	}
+}
+
+func TestReplayRejectsFingerprintMismatch(t *testing.T) {
+	server := newTestServer(t, time.Hour, time.Minute)
+
+	server.expect(server.do("/messages", "alice", "key", `{"content":"a"}`), http.StatusCreated, "", false)
+	server.expect(server.do("/messages", "alice", "key", `{"content":"b"}`), http.StatusUnprocessableEntity, "", false)
+	server.expect(server.do("/deliveries", "alice", "key", `{"content":"a"}`), http.StatusUnprocessableEntity, "", false)
+
+	if server.executions != 1 {
+		t.Fatalf("expected 1 execution, got %d", server.executions)
+	}
+}
+
+func TestReplayRejectsInvalidAndInProgressKeys(t *testing.T) {
+	server := newTestServer(t, time.Hour, time.Minute)
+
+	server.expect(server.do("/messages", "alice", strings.Repeat("k", maxKeyLength+1), `{}`), http.StatusBadRequest, "", false)
+
+	_, err := server.dataStore.Insert(context.Background(), &Record{
+		Principal:   "alice",
+		Key:         "pending",
+		Fingerprint: fingerprint(http.MethodPost, "/messages", `{}`),
+		Body:        []byte{},
+		ReservedAt:  time.Now().UnixNano(),
+		CreatedAt:   time.Now().UnixNano(),
+		ExpiresAt:   time.Now().Add(time.Hour).UnixNano(),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.expect(server.do("/messages", "alice", "pending", `{}`), http.StatusConflict, "", false)
+	server.expect(server.do("/messages", "alice", "pending", `{"content":"a"}`), http.StatusUnprocessableEntity, "", false)
+
+	if server.executions != 0 {
+		t.Fatalf("expected no executions, got %d", server.executions)
+	}
+}
+
+func TestReplayReleasesKeyAfterServerError(t *testing.T) {
+	server := newTestServer(t, time.Hour, time.Minute)
+	server.status = http.StatusInternalServerError
+
+	server.expect(server.do("/messages", "alice", "key", `{}`), http.StatusInternalServerError, "", false)
+
+	server.status = http.StatusCreated
+
+	server.expect(server.do("/messages", "alice", "key", `{}`), http.StatusCreated, `{"execution":2}`, false)
+	server.expect(server.do("/messages", "alice", "key", `{}`), http.StatusCreated, `{"execution":2}`, true)
+
+	server.status = http.StatusBadRequest
+
+	server.expect(server.do("/messages", "alice", "client-error", `{}`), http.StatusBadRequest, `{"execution":3}`, false)
+	server.expect(server.do("/messages", "alice", "client-error", `{}`), http.StatusBadRequest, `{"execution":3}`, true)
+}
+
+func TestReplayExpiresKeys(t *testing.T) {
+	server := newTestServer(t, time.Millisecond, time.Minute)
+
+	for i := 1; i <= 2; i++ {
+		server.expect(server.do("/messages", "alice", "key", fmt.Sprintf(`{"content":%d}`, i)), http.StatusCreated, fmt.Sprintf(`{"execution":%d}`, i), false)
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReplayReclaimsStaleReservations(t *testing.T) {
+	server := newTestServer(t, time.Hour, time.Minute)
+
+	_, err := server.dataStore.Insert(context.Background(), &Record{
+		Principal:   "alice",
+		Key:         "stale",
+		Fingerprint: fingerprint(http.MethodPost, "/messages", `{}`),
+		Body:        []byte{},
+		ReservedAt:  time.Now().Add(-2 * time.Minute).UnixNano(),
+		CreatedAt:   time.Now().Add(-2 * time.Minute).UnixNano(),
+		ExpiresAt:   time.Now().Add(time.Hour).UnixNano(),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.expect(server.do("/messages", "alice", "stale", `{}`), http.StatusCreated, `{"execution":1}`, false)
+	server.expect(server.do("/messages", "alice", "stale", `{}`), http.StatusCreated, `{"execution":1}`, true)
+}
+
+func TestReplayReleasesKeyAfterPanic(t *testing.T) {
+	server := newTestServer(t, time.Hour, time.Minute)
+	server.panics = true
+
+	server.expect(server.do("/messages", "alice", "key", `{}`), http.StatusInternalServerError, "", false)
+
+	server.panics = false
+
+	server.expect(server.do("/messages", "alice", "key", `{}`), http.StatusCreated, `{"execution":2}`, false)
+}
+
+func TestReplayAnonymousScopesKeysByBody(t *testing.T) {
+	server := newTestServer(t, time.Hour, time.Minute)
+
+	server.expect(server.do("/signup", "", "key", `{"identifier":"alice"}`), http.StatusCreated, `{"execution":1}`, false)
+	server.expect(server.do("/signup", "", "key", `{"identifier":"alice"}`), http.StatusCreated, `{"execution":1}`, true)
+	server.expect(server.do("/signup", "", "key", `{"identifier":"bob"}`), http.StatusCreated, `{"execution":2}`, false)
+	server.expect(server.do("/signup", "", "", `{"identifier":"alice"}`), http.StatusCreated, `{"execution":3}`, false)
+}
@@ -0,0 +1,14 @@
+package idempotency
+
+type Record struct {
+	Principal   string `json:"principal" db:"principal"`
+	Key         string `json:"key" db:"key"`
+	Fingerprint string `json:"fingerprint" db:"fingerprint"`
+	StatusCode  int    `json:"status_code" db:"status_code"`
+	ContentType string `json:"content_type" db:"content_type"`
+	Body        []byte `json:"body" db:"body"`
+	Completed   bool   `json:"completed" db:"completed"`
+	ReservedAt  int64  `json:"reserved_at" db:"reserved_at"`
+	CreatedAt   int64  `json:"created_at" db:"created_at"`
+	ExpiresAt   int64  `json:"expires_at" db:"expires_at"`
+}
@@ -0,0 +1,271 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"go.uber.org/zap"
+)
+
+type BlobDataStore struct {
+	db *sql.DB
+}
+
+func NewBlobDataStore(db *sql.DB) *BlobDataStore {
+	return &BlobDataStore{db: db}
+}
+
+func (d *BlobDataStore) Upsert(ctx context.Context, blob *Blob) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO blobs (hash, size, content_type, stored, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (hash) DO UPDATE SET stored = MAX(stored, excluded.stored), updated_at = excluded.updated_at",
+		blob.Hash,
+		blob.Size,
+		blob.ContentType,
+		blob.Stored,
+		blob.CreatedAt,
+		blob.UpdatedAt)
+
+	return err
+}
+
+func (d *BlobDataStore) InsertIfNotExists(ctx context.Context, blob *Blob) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO blobs (hash, size, content_type, stored, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (hash) DO NOTHING",
+		blob.Hash,
+		blob.Size,
+		blob.ContentType,
+		blob.Stored,
+		blob.CreatedAt,
+		blob.UpdatedAt)
+
+	return err
+}
+
+func (d *BlobDataStore) FindByHash(ctx context.Context, hash string) (*Blob, error) {
+	var blob Blob
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT hash, size, content_type, stored, created_at, updated_at FROM blobs WHERE hash = ?",
+		hash).
+		Scan(&blob.Hash, &blob.Size, &blob.ContentType, &blob.Stored, &blob.CreatedAt, &blob.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &blob, nil
+}
+
+func (d *BlobDataStore) DeleteUnreferencedByHash(ctx context.Context, hash string) (bool, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM blobs WHERE hash = ? AND NOT EXISTS (SELECT 1 FROM blob_references r WHERE r.hash = blobs.hash)", hash)
+
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	if err != nil || n == 0 {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM blob_uploads WHERE hash = ?", hash)
+
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (d *BlobDataStore) InsertUpload(ctx context.Context, hash string, actorAddress string, nodeIdentifier string, createdAt int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO blob_uploads (hash, actor_address, node_identifier, created_at) VALUES (?, ?, ?, ?) ON CONFLICT (hash, actor_address, node_identifier) DO NOTHING",
+		hash, actorAddress, nodeIdentifier, createdAt)
+
+	return err
+}
+
+func (d *BlobDataStore) ExistsUploadByHashAndActorAddressAndNodeIdentifier(ctx context.Context, hash string, actorAddress string, nodeIdentifier string) (bool, error) {
+	var count int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM blob_uploads WHERE hash = ? AND actor_address = ? AND node_identifier = ?",
+		hash, actorAddress, nodeIdentifier).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *BlobDataStore) InsertReference(ctx context.Context, hash string, message *Message, verified bool) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO blob_references (hash, message_identifier, box_type, box_identifier, node_identifier, actor_address, verified, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (hash, message_identifier, box_type, box_identifier, node_identifier) DO NOTHING",
+		hash,
+		message.Identifier,
+		message.BoxType,
+		message.BoxIdentifier,
+		message.NodeIdentifier,
+		message.ActorAddress,
+		verified,
+		message.CreatedAt)
+
+	return err
+}
+
+func (d *BlobDataStore) UpdateReferencesVerifiedByHashAndActorAddressAndNodeIdentifier(ctx context.Context, hash string, actorAddress string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE blob_references SET verified = 1 WHERE hash = ? AND actor_address = ? AND node_identifier = ?",
+		hash, actorAddress, nodeIdentifier)
+
+	return err
+}
+
+func (d *BlobDataStore) ExistsReferenceByHashAndActorAddressAndNodeIdentifier(ctx context.Context, hash string, actorAddress string, nodeIdentifier string) (bool, error) {
+	var count int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM blob_references WHERE hash = ? AND actor_address = ? AND node_identifier = ?",
+		hash, actorAddress, nodeIdentifier).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *BlobDataStore) ExistsVerifiedReferenceByHashAndActorAddressAndNodeIdentifier(ctx context.Context, hash string, actorAddress string, nodeIdentifier string) (bool, error) {
+	var count int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM blob_references WHERE hash = ? AND actor_address = ? AND node_identifier = ? AND verified = 1",
+		hash, actorAddress, nodeIdentifier).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *BlobDataStore) ExistsOutboxReferenceByHashAndNodeIdentifierAndRecipientActorAddress(ctx context.Context, hash string, nodeIdentifier string, recipientActorAddress string) (bool, error) {
+	var count int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM blob_references r JOIN messages m ON m.identifier = r.message_identifier AND m.box_type = r.box_type AND m.box_identifier = r.box_identifier AND m.node_identifier = r.node_identifier WHERE r.hash = ? AND r.box_type = ? AND r.node_identifier = ? AND (substr(m.recipient_address, 1, ?) = ? OR EXISTS (SELECT 1 FROM json_each(NULLIF(m.recipient_addresses, '')) WHERE substr(value, 1, ?) = ?))",
+		hash, BoxTypeOutbox, nodeIdentifier, len(recipientActorAddress)+1, recipientActorAddress+"/", len(recipientActorAddress)+1, recipientActorAddress+"/").Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *BlobDataStore) FindInboxMessageByHashAndActorAddressAndNodeIdentifier(ctx context.Context, hash string, actorAddress string, nodeIdentifier string) (*Message, error) {
+	var message Message
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT m.identifier, m.sender_address, m.recipient_address FROM blob_references r JOIN messages m ON m.identifier = r.message_identifier AND m.box_type = r.box_type AND m.box_identifier = r.box_identifier AND m.node_identifier = r.node_identifier WHERE r.hash = ? AND r.box_type = ? AND r.actor_address = ? AND r.node_identifier = ? ORDER BY r.created_at DESC LIMIT 1",
+		hash, BoxTypeInbox, actorAddress, nodeIdentifier).
+		Scan(&message.Identifier, &message.SenderAddress, &message.RecipientAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (d *BlobDataStore) FindHashesByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string) ([]string, error) {
+	return d.findHashes(ctx,
+		"SELECT DISTINCT hash FROM blob_references WHERE box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		boxType, boxIdentifier, nodeIdentifier)
+}
+
+func (d *BlobDataStore) findHashes(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var hashes []string
+
+	for rows.Next() {
+		var hash string
+		err = rows.Scan(&hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+func (d *BlobDataStore) DeleteReferencesByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM blob_references WHERE box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		boxType, boxIdentifier, nodeIdentifier)
+
+	return err
+}
+
+func (d *BlobDataStore) FindHashesByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, messageIdentifier string, boxType string, boxIdentifier string, nodeIdentifier string) ([]string, error) {
+	return d.findHashes(ctx,
+		"SELECT DISTINCT hash FROM blob_references WHERE message_identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		messageIdentifier, boxType, boxIdentifier, nodeIdentifier)
+}
+
+func (d *BlobDataStore) DeleteReferenceByHashAndMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, hash string, messageIdentifier string, boxType string, boxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM blob_references WHERE hash = ? AND message_identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		hash, messageIdentifier, boxType, boxIdentifier, nodeIdentifier)
+
+	return err
+}
+
+func (d *BlobDataStore) DeleteReferencesByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, messageIdentifier string, boxType string, boxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM blob_references WHERE message_identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		messageIdentifier, boxType, boxIdentifier, nodeIdentifier)
+
+	return err
+}
+
+func (d *BlobDataStore) FindUnreferencedHashesByCreatedAtBefore(ctx context.Context, createdAt int64, size int64) ([]string, error) {
+	return d.findHashes(ctx,
+		"SELECT b.hash FROM blobs b WHERE b.created_at < ? AND NOT EXISTS (SELECT 1 FROM blob_references r WHERE r.hash = b.hash) ORDER BY b.created_at LIMIT ?",
+		createdAt, size)
+}
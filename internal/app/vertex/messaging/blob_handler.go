@@ -0,0 +1,93 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	blobTransferTimeout = 10 * time.Minute
+)
+
+type BlobHandler struct {
+	router        *gin.Engine
+	authenticator *actor.Authenticator
+	manager       *BlobManager
+}
+
+func NewBlobHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *BlobManager) *BlobHandler {
+	return &BlobHandler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *BlobHandler) Register() {
+
+	h.router.POST("/api/v1/messaging/blobs", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, blobTransferTimeout)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		contentType := c.ContentType()
+
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		blob, err := h.manager.Upload(ctx, c.Request.Body, contentType, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, blob)
+	})
+
+	h.router.GET("/api/v1/messaging/blobs/:hash", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, blobTransferTimeout)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		hash := c.Param("hash")
+
+		if !isValidBlobHash(hash) {
+			api.ErrorMessage(c, http.StatusBadRequest, "invalid blob hash")
+			return
+		}
+
+		blob, file, err := h.manager.Open(ctx, hash, authenticatedActor)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		defer func(file *os.File) {
+			err := file.Close()
+			if err != nil {
+				zap.L().Error("error closing blob", zap.String("hash", hash), zap.Error(err))
+			}
+		}(file)
+
+		c.Header("Content-Type", blob.ContentType)
+		c.Header("ETag", "\""+blob.Hash+"\"")
+		c.Header("Cache-Control", "private, max-age=31536000, immutable")
+
+		http.ServeContent(c.Writer, c.Request, "", time.Unix(0, blob.CreatedAt), file)
+	})
+}
@@ -0,0 +1,481 @@
+package messaging
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/app/vertex/discovery"
+	"github.com/evernetproto/evernet/internal/app/vertex/node"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	BlobDirectory    = "blobs"
+	blobMaxSize      = 64 << 20
+	blobFetchTimeout = 5 * time.Minute
+	blobOrphanAge    = 24 * time.Hour
+)
+
+type BlobManager struct {
+	vertex           string
+	root             string
+	dataStore        *BlobDataStore
+	nodeManager      *node.Manager
+	authenticator    *actor.Authenticator
+	federationClient *federation.Client
+	discoveryManager *discovery.RemoteManager
+}
+
+func NewBlobManager(vertex string, dataPath string, dataStore *BlobDataStore, nodeManager *node.Manager, authenticator *actor.Authenticator, federationClient *federation.Client, discoveryManager *discovery.RemoteManager) *BlobManager {
+	return &BlobManager{
+		vertex:           vertex,
+		root:             filepath.Join(dataPath, BlobDirectory),
+		dataStore:        dataStore,
+		nodeManager:      nodeManager,
+		authenticator:    authenticator,
+		federationClient: federationClient,
+		discoveryManager: discoveryManager,
+	}
+}
+
+func (m *BlobManager) Upload(ctx context.Context, reader io.Reader, contentType string, actorAddress string, nodeIdentifier string) (*Blob, error) {
+	hash, size, err := m.write(reader, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	blob := &Blob{
+		Hash:        hash,
+		Size:        size,
+		ContentType: contentType,
+		Stored:      true,
+		CreatedAt:   time.Now().UnixNano(),
+		UpdatedAt:   time.Now().UnixNano(),
+	}
+
+	err = m.dataStore.Upsert(ctx, blob)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.dataStore.InsertUpload(ctx, hash, actorAddress, nodeIdentifier, blob.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dataStore.FindByHash(ctx, hash)
+}
+
+func (m *BlobManager) Open(ctx context.Context, hash string, authenticatedActor *actor.AuthenticatedActor) (*Blob, *os.File, error) {
+	blob, err := m.dataStore.FindByHash(ctx, hash)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, reject(http.StatusNotFound, "blob %s not found", hash)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowed, verified, err := m.isAllowed(ctx, hash, authenticatedActor)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !allowed {
+		return nil, nil, reject(http.StatusNotFound, "blob %s not found", hash)
+	}
+
+	if !blob.Stored || !verified {
+		err = m.fetch(ctx, blob, authenticatedActor)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	file, err := os.Open(m.path(hash))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blob, file, nil
+}
+
+func (m *BlobManager) Validate(ctx context.Context, attachments []*Attachment, actorAddress string, nodeIdentifier string) error {
+	for _, attachment := range attachments {
+		if !isValidBlobHash(attachment.Hash) {
+			return fmt.Errorf("invalid attachment hash %s", attachment.Hash)
+		}
+
+		blob, err := m.dataStore.FindByHash(ctx, attachment.Hash)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("blob %s not found", attachment.Hash)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		uploaded, err := m.dataStore.ExistsUploadByHashAndActorAddressAndNodeIdentifier(ctx, attachment.Hash, actorAddress, nodeIdentifier)
+
+		if err != nil {
+			return err
+		}
+
+		referenced, err := m.dataStore.ExistsVerifiedReferenceByHashAndActorAddressAndNodeIdentifier(ctx, attachment.Hash, actorAddress, nodeIdentifier)
+
+		if err != nil {
+			return err
+		}
+
+		if !uploaded && !referenced {
+			return fmt.Errorf("blob %s not found", attachment.Hash)
+		}
+
+		attachment.Size = blob.Size
+
+		if attachment.ContentType == "" {
+			attachment.ContentType = blob.ContentType
+		}
+	}
+
+	return nil
+}
+
+func (m *BlobManager) Reference(ctx context.Context, message *Message) error {
+	sender, err := ParseActorAddress(message.SenderAddress)
+
+	if err != nil {
+		return err
+	}
+
+	verified := sender.Vertex == m.vertex
+
+	for _, attachment := range message.Attachments {
+		err = m.dataStore.InsertIfNotExists(ctx, &Blob{
+			Hash:        attachment.Hash,
+			Size:        attachment.Size,
+			ContentType: attachment.ContentType,
+			Stored:      false,
+			CreatedAt:   time.Now().UnixNano(),
+			UpdatedAt:   time.Now().UnixNano(),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		err = m.dataStore.InsertReference(ctx, attachment.Hash, message, verified)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *BlobManager) Release(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string) error {
+	hashes, err := m.dataStore.FindHashesByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, boxType, boxIdentifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.dataStore.DeleteReferencesByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, boxType, boxIdentifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		err = m.collect(ctx, hash)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *BlobManager) ReleaseMessage(ctx context.Context, message *Message) error {
+	hashes, err := m.dataStore.FindHashesByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message.Identifier, message.BoxType, message.BoxIdentifier, message.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.dataStore.DeleteReferencesByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message.Identifier, message.BoxType, message.BoxIdentifier, message.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		err = m.collect(ctx, hash)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *BlobManager) Replace(ctx context.Context, previous *Message, message *Message) error {
+	err := m.Reference(ctx, message)
+
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range previous.Attachments {
+		if slices.ContainsFunc(message.Attachments, func(current *Attachment) bool { return current.Hash == attachment.Hash }) {
+			continue
+		}
+
+		err = m.dataStore.DeleteReferenceByHashAndMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, attachment.Hash, previous.Identifier, previous.BoxType, previous.BoxIdentifier, previous.NodeIdentifier)
+
+		if err != nil {
+			return err
+		}
+
+		err = m.collect(ctx, attachment.Hash)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *BlobManager) CollectOrphans(ctx context.Context, size int64) (int, error) {
+	hashes, err := m.dataStore.FindUnreferencedHashesByCreatedAtBefore(ctx, time.Now().Add(-blobOrphanAge).UnixNano(), size)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, hash := range hashes {
+		err = m.collect(ctx, hash)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(hashes), nil
+}
+
+func (m *BlobManager) collect(ctx context.Context, hash string) error {
+	deleted, err := m.dataStore.DeleteUnreferencedByHash(ctx, hash)
+
+	if err != nil || !deleted {
+		return err
+	}
+
+	err = os.Remove(m.path(hash))
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	zap.L().Debug("collected blob", zap.String("hash", hash))
+
+	return nil
+}
+
+func (m *BlobManager) isAllowed(ctx context.Context, hash string, authenticatedActor *actor.AuthenticatedActor) (bool, bool, error) {
+	uploaded, err := m.dataStore.ExistsUploadByHashAndActorAddressAndNodeIdentifier(ctx, hash, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+	if err != nil || uploaded {
+		return uploaded, true, err
+	}
+
+	verified, err := m.dataStore.ExistsVerifiedReferenceByHashAndActorAddressAndNodeIdentifier(ctx, hash, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+	if err != nil || verified {
+		return verified, true, err
+	}
+
+	referenced, err := m.dataStore.ExistsReferenceByHashAndActorAddressAndNodeIdentifier(ctx, hash, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+	if err != nil || referenced {
+		return referenced, false, err
+	}
+
+	outbox, err := m.dataStore.ExistsOutboxReferenceByHashAndNodeIdentifierAndRecipientActorAddress(ctx, hash, authenticatedActor.TargetNodeIdentifier, authenticatedActor.Address)
+
+	return outbox, true, err
+}
+
+func (m *BlobManager) fetch(ctx context.Context, blob *Blob, authenticatedActor *actor.AuthenticatedActor) error {
+	message, err := m.dataStore.FindInboxMessageByHashAndActorAddressAndNodeIdentifier(ctx, blob.Hash, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "blob %s is not stored on this vertex", blob.Hash)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	sender, err := ParseActorAddress(message.SenderAddress)
+
+	if err != nil {
+		return err
+	}
+
+	recipient, err := ParseInboxAddress(message.RecipientAddress)
+
+	if err != nil {
+		return err
+	}
+
+	if sender.Vertex == m.vertex {
+		return reject(http.StatusNotFound, "blob %s is not stored on this vertex", blob.Hash)
+	}
+
+	recipientNode, err := m.nodeManager.Get(ctx, recipient.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	token, err := m.authenticator.GenerateToken(recipient.ActorIdentifier, recipientNode, sender.GetNodeAddress())
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, blobFetchTimeout)
+	defer cancel()
+
+	url, err := m.discoveryManager.URL(ctx, sender.Vertex, discovery.EndpointBlob, blob.Hash)
+
+	if err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	result := make(chan error, 1)
+
+	go func() {
+		_, err := m.federationClient.Download(ctx, url, token, writer)
+		_ = writer.CloseWithError(err)
+		result <- err
+	}()
+
+	_, _, err = m.write(reader, blob.Hash)
+	_ = reader.CloseWithError(err)
+
+	if downloadErr := <-result; downloadErr != nil {
+		return downloadErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	blob.Stored = true
+	blob.UpdatedAt = time.Now().UnixNano()
+
+	err = m.dataStore.Upsert(ctx, blob)
+
+	if err != nil {
+		return err
+	}
+
+	return m.dataStore.UpdateReferencesVerifiedByHashAndActorAddressAndNodeIdentifier(ctx, blob.Hash, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+}
+
+func (m *BlobManager) write(reader io.Reader, expectedHash string) (string, int64, error) {
+	temporaryDirectory := filepath.Join(m.root, "tmp")
+
+	err := os.MkdirAll(temporaryDirectory, os.ModePerm)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	file, err := os.CreateTemp(temporaryDirectory, "blob-*")
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	digest := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, digest), io.LimitReader(reader, blobMaxSize+1))
+
+	closeErr := file.Close()
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	if closeErr != nil {
+		return "", 0, closeErr
+	}
+
+	if size > blobMaxSize {
+		return "", 0, reject(http.StatusRequestEntityTooLarge, "blob exceeds maximum size of %d bytes", blobMaxSize)
+	}
+
+	hash := hex.EncodeToString(digest.Sum(nil))
+
+	if expectedHash != "" && hash != expectedHash {
+		return "", 0, fmt.Errorf("blob hash mismatch: expected %s, got %s", expectedHash, hash)
+	}
+
+	err = os.MkdirAll(filepath.Dir(m.path(hash)), os.ModePerm)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	err = os.Rename(file.Name(), m.path(hash))
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hash, size, nil
+}
+
+func (m *BlobManager) path(hash string) string {
+	return filepath.Join(m.root, hash[:2], hash)
+}
+
+func isValidBlobHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash)
+	return err == nil && hash == strings.ToLower(hash)
+}
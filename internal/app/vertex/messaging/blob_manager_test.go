@@ -0,0 +1,24 @@
+package messaging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsValidBlobHash(t *testing.T) {
+	tests := map[string]bool{
+		"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08": true,
+		"9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08": false,
+		"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00A08": false,
+		"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a0":  false,
+		"../../../../../../../../../../../../../../../../../../etc/passwd": false,
+		strings.Repeat("g", 64): false,
+		"":                      false,
+	}
+
+	for hash, valid := range tests {
+		if isValidBlobHash(hash) != valid {
+			t.Errorf("isValidBlobHash(%q): expected %t", hash, valid)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"go.uber.org/zap"
+	"strings"
+)
+
+type DeliveryDataStore struct {
+	db *sql.DB
+}
+
+func NewDeliveryDataStore(db *sql.DB) *DeliveryDataStore {
+	return &DeliveryDataStore{db: db}
+}
+
+func (d *DeliveryDataStore) Insert(ctx context.Context, delivery *Delivery) (*Delivery, error) {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO deliveries (identifier, message_identifier, outbox_identifier, node_identifier, sender_address, recipient_address, destination_vertex, revision, kind, status, attempts, last_error, next_attempt_at, read_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		delivery.Identifier,
+		delivery.MessageIdentifier,
+		delivery.OutboxIdentifier,
+		delivery.NodeIdentifier,
+		delivery.SenderAddress,
+		delivery.RecipientAddress,
+		delivery.DestinationVertex,
+		delivery.Revision,
+		delivery.Kind,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.ReadAt,
+		delivery.CreatedAt,
+		delivery.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (d *DeliveryDataStore) FindDue(ctx context.Context, now int64, size int64) ([]*Delivery, error) {
+	return d.findAll(ctx,
+		"SELECT identifier, message_identifier, outbox_identifier, node_identifier, sender_address, recipient_address, destination_vertex, revision, kind, status, attempts, last_error, next_attempt_at, read_at, created_at, updated_at FROM deliveries WHERE status = ? AND next_attempt_at <= ? AND destination_vertex NOT IN (SELECT vertex FROM delivery_backoffs WHERE next_attempt_at > ?) ORDER BY next_attempt_at LIMIT ?",
+		DeliveryStatusPending, now, now, size)
+}
+
+func (d *DeliveryDataStore) FindByStatusIn(ctx context.Context, statuses []string, pagination *api.Pagination) ([]*Delivery, error) {
+	args := make([]interface{}, 0, len(statuses)+4)
+
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+
+	createdAt, identifier := pagination.Bounds(true)
+	args = append(args, createdAt, identifier, pagination.Size, pagination.Offset())
+
+	return d.findAll(ctx,
+		"SELECT identifier, message_identifier, outbox_identifier, node_identifier, sender_address, recipient_address, destination_vertex, revision, kind, status, attempts, last_error, next_attempt_at, read_at, created_at, updated_at FROM deliveries WHERE status IN ("+placeholders(len(statuses))+") AND (created_at, identifier) < (?, ?) ORDER BY created_at DESC, identifier DESC LIMIT ? OFFSET ?",
+		args...)
+}
+
+func (d *DeliveryDataStore) FindByMessageIdentifierAndOutboxIdentifierAndNodeIdentifier(ctx context.Context, messageIdentifier string, outboxIdentifier string, nodeIdentifier string) ([]*Delivery, error) {
+	return d.findAll(ctx,
+		"SELECT identifier, message_identifier, outbox_identifier, node_identifier, sender_address, recipient_address, destination_vertex, revision, kind, status, attempts, last_error, next_attempt_at, read_at, created_at, updated_at FROM deliveries WHERE message_identifier = ? AND outbox_identifier = ? AND node_identifier = ? ORDER BY created_at",
+		messageIdentifier, outboxIdentifier, nodeIdentifier)
+}
+
+func (d *DeliveryDataStore) findAll(ctx context.Context, query string, args ...interface{}) ([]*Delivery, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var deliveries []*Delivery
+
+	for rows.Next() {
+		var delivery Delivery
+		err = rows.Scan(
+			&delivery.Identifier,
+			&delivery.MessageIdentifier,
+			&delivery.OutboxIdentifier,
+			&delivery.NodeIdentifier,
+			&delivery.SenderAddress,
+			&delivery.RecipientAddress,
+			&delivery.DestinationVertex,
+			&delivery.Revision,
+			&delivery.Kind,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.ReadAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (d *DeliveryDataStore) FindByIdentifierAndStatusIn(ctx context.Context, identifier string, statuses []string) (*Delivery, error) {
+	var delivery Delivery
+
+	args := make([]interface{}, 0, len(statuses)+1)
+	args = append(args, identifier)
+
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT identifier, message_identifier, outbox_identifier, node_identifier, sender_address, recipient_address, destination_vertex, revision, kind, status, attempts, last_error, next_attempt_at, read_at, created_at, updated_at FROM deliveries WHERE identifier = ? AND status IN ("+placeholders(len(statuses))+")",
+		args...).
+		Scan(
+			&delivery.Identifier,
+			&delivery.MessageIdentifier,
+			&delivery.OutboxIdentifier,
+			&delivery.NodeIdentifier,
+			&delivery.SenderAddress,
+			&delivery.RecipientAddress,
+			&delivery.DestinationVertex,
+			&delivery.Revision,
+			&delivery.Kind,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.ReadAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (d *DeliveryDataStore) UpdateStatusByIdentifierAndStatus(ctx context.Context, newStatus string, identifier string, status string, updatedAt int64) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE deliveries SET status = ?, updated_at = ? WHERE identifier = ? AND status = ?",
+		newStatus, updatedAt, identifier, status)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *DeliveryDataStore) UpdateStatusByMessageIdentifierAndOutboxIdentifierAndNodeIdentifierAndStatus(ctx context.Context, newStatus string, messageIdentifier string, outboxIdentifier string, nodeIdentifier string, status string, updatedAt int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE deliveries SET status = ?, updated_at = ? WHERE message_identifier = ? AND outbox_identifier = ? AND node_identifier = ? AND status = ?",
+		newStatus, updatedAt, messageIdentifier, outboxIdentifier, nodeIdentifier, status)
+
+	return err
+}
+
+func (d *DeliveryDataStore) ExistsByMessageIdentifierAndOutboxIdentifierAndNodeIdentifierAndStatusIn(ctx context.Context, messageIdentifier string, outboxIdentifier string, nodeIdentifier string, statuses []string) (bool, error) {
+	args := make([]interface{}, 0, len(statuses)+3)
+	args = append(args, messageIdentifier, outboxIdentifier, nodeIdentifier)
+
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+
+	var count int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM deliveries WHERE message_identifier = ? AND outbox_identifier = ? AND node_identifier = ? AND status IN ("+placeholders(len(statuses))+")",
+		args...).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *DeliveryDataStore) UpdateStatusByStatus(ctx context.Context, newStatus string, status string, updatedAt int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE deliveries SET status = ?, updated_at = ? WHERE status = ?",
+		newStatus, updatedAt, status)
+
+	return err
+}
+
+func (d *DeliveryDataStore) UpdateStatusAndAttemptsAndLastErrorAndNextAttemptAtByIdentifier(ctx context.Context, status string, attempts int64, lastError string, nextAttemptAt int64, identifier string, updatedAt int64) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE deliveries SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ? WHERE identifier = ?",
+		status, attempts, lastError, nextAttemptAt, updatedAt, identifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *DeliveryDataStore) UpdateReadAtByMessageIdentifierAndSenderAddressAndRecipientAddress(ctx context.Context, readAt int64, messageIdentifier string, senderAddress string, recipientAddress string, updatedAt int64) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE deliveries SET read_at = CASE WHEN read_at = 0 THEN ? ELSE read_at END, updated_at = ? WHERE message_identifier = ? AND sender_address = ? AND recipient_address = ? AND kind = ?",
+		readAt, updatedAt, messageIdentifier, senderAddress, recipientAddress, DeliveryKindMessage)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *DeliveryDataStore) FindBackoffByVertex(ctx context.Context, vertex string) (*DeliveryBackoff, error) {
+	var backoff DeliveryBackoff
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT vertex, failures, next_attempt_at, updated_at FROM delivery_backoffs WHERE vertex = ?", vertex).
+		Scan(&backoff.Vertex, &backoff.Failures, &backoff.NextAttemptAt, &backoff.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &backoff, nil
+}
+
+func (d *DeliveryDataStore) UpsertBackoff(ctx context.Context, backoff *DeliveryBackoff) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO delivery_backoffs (vertex, failures, next_attempt_at, updated_at) VALUES (?, ?, ?, ?) ON CONFLICT (vertex) DO UPDATE SET failures = excluded.failures, next_attempt_at = excluded.next_attempt_at, updated_at = excluded.updated_at",
+		backoff.Vertex, backoff.Failures, backoff.NextAttemptAt, backoff.UpdatedAt)
+
+	return err
+}
+
+func (d *DeliveryDataStore) DeleteBackoffByVertex(ctx context.Context, vertex string) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM delivery_backoffs WHERE vertex = ?", vertex)
+	return err
+}
+
+func placeholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strings.Repeat("?, ", n-1) + "?"
+}
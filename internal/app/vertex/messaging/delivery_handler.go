@@ -0,0 +1,95 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/admin"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type DeliveryHandler struct {
+	router        *gin.Engine
+	authenticator *admin.Authenticator
+	manager       *DeliveryManager
+}
+
+func NewDeliveryHandler(router *gin.Engine, authenticator *admin.Authenticator, manager *DeliveryManager) *DeliveryHandler {
+	return &DeliveryHandler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *DeliveryHandler) Register() {
+
+	h.router.GET("/api/v1/messaging/dead-letters", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		deliveries, err := h.manager.ListDeadLetters(ctx, pagination)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Paginated(c, http.StatusOK, pagination, deliveries, func(delivery *Delivery) *api.Cursor {
+			return &api.Cursor{CreatedAt: delivery.CreatedAt, Identifier: delivery.Identifier}
+		})
+	})
+
+	h.router.GET("/api/v1/messaging/dead-letters/:deliveryIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("deliveryIdentifier")
+
+		delivery, err := h.manager.GetDeadLetter(ctx, identifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, delivery)
+	})
+
+	h.router.POST("/api/v1/messaging/dead-letters/:deliveryIdentifier/redrive", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("deliveryIdentifier")
+
+		delivery, err := h.manager.Redrive(ctx, identifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, delivery)
+	})
+}
@@ -0,0 +1,651 @@
+package messaging
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/app/vertex/node"
+	"github.com/evernetproto/evernet/internal/app/vertex/policy"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
+	"github.com/evernetproto/evernet/internal/pkg/ids"
+	"go.uber.org/zap"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	deliveryBatchSize      = 50
+	deliveryAttemptTimeout = 30 * time.Second
+	deliveryBaseBackoff    = 10 * time.Second
+	deliveryMaxBackoff     = time.Hour
+)
+
+var deadLetterStatuses = []string{DeliveryStatusRejected, DeliveryStatusDead}
+
+type DeliveryManager struct {
+	vertex              string
+	maxAttempts         int64
+	dataStore           *DeliveryDataStore
+	messageDataStore    *MessageDataStore
+	remoteInboxManager  *RemoteInboxManager
+	remoteOutboxManager *RemoteOutboxManager
+	nodeManager         *node.Manager
+	authenticator       *actor.Authenticator
+	webhookManager      *WebhookManager
+	policyManager       *policy.Manager
+	notifications       chan struct{}
+}
+
+func NewDeliveryManager(
+	vertex string,
+	maxAttempts int64,
+	dataStore *DeliveryDataStore,
+	messageDataStore *MessageDataStore,
+	remoteInboxManager *RemoteInboxManager,
+	remoteOutboxManager *RemoteOutboxManager,
+	nodeManager *node.Manager,
+	authenticator *actor.Authenticator,
+	webhookManager *WebhookManager,
+	policyManager *policy.Manager,
+) *DeliveryManager {
+	return &DeliveryManager{
+		vertex:              vertex,
+		maxAttempts:         maxAttempts,
+		dataStore:           dataStore,
+		messageDataStore:    messageDataStore,
+		remoteInboxManager:  remoteInboxManager,
+		remoteOutboxManager: remoteOutboxManager,
+		nodeManager:         nodeManager,
+		authenticator:       authenticator,
+		webhookManager:      webhookManager,
+		policyManager:       policyManager,
+		notifications:       make(chan struct{}, 1),
+	}
+}
+
+func (m *DeliveryManager) Enqueue(ctx context.Context, message *Message, recipients []*InboxAddress) ([]*Delivery, error) {
+	var deliveries []*Delivery
+
+	for _, recipient := range recipients {
+		err := m.policyManager.Check(ctx, recipient.Vertex, recipient.NodeIdentifier)
+
+		var blockedError *policy.BlockedError
+
+		if errors.As(err, &blockedError) {
+			delivery, err := m.RecordRejected(ctx, message, recipient, blockedError)
+
+			if err != nil {
+				return nil, err
+			}
+
+			deliveries = append(deliveries, delivery)
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		delivery, err := m.insert(ctx, message, recipient, DeliveryStatusPending, 0, "")
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	m.notify()
+
+	return deliveries, nil
+}
+
+func (m *DeliveryManager) RecordDelivered(ctx context.Context, message *Message, recipient *InboxAddress) (*Delivery, error) {
+	return m.insert(ctx, message, recipient, DeliveryStatusDelivered, 1, "")
+}
+
+func (m *DeliveryManager) RecordRejected(ctx context.Context, message *Message, recipient *InboxAddress, cause error) (*Delivery, error) {
+	delivery, err := m.insert(ctx, message, recipient, DeliveryStatusRejected, 1, cause.Error())
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.failed(ctx, delivery)
+
+	return delivery, nil
+}
+
+func (m *DeliveryManager) insert(ctx context.Context, message *Message, recipient *InboxAddress, status string, attempts int64, lastError string) (*Delivery, error) {
+	deliveryIdentifier, err := ids.Generate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &Delivery{
+		Identifier:        deliveryIdentifier,
+		MessageIdentifier: message.Identifier,
+		OutboxIdentifier:  message.BoxIdentifier,
+		NodeIdentifier:    message.NodeIdentifier,
+		SenderAddress:     message.SenderAddress,
+		RecipientAddress:  recipient.String(),
+		DestinationVertex: recipient.Vertex,
+		Revision:          message.Revision,
+		Kind:              DeliveryKindMessage,
+		Status:            status,
+		Attempts:          attempts,
+		LastError:         lastError,
+		NextAttemptAt:     time.Now().UnixNano(),
+		CreatedAt:         time.Now().UnixNano(),
+		UpdatedAt:         time.Now().UnixNano(),
+	}
+
+	return m.dataStore.Insert(ctx, delivery)
+}
+
+func (m *DeliveryManager) EnqueueReceipt(ctx context.Context, receipt *ReceiptRequest, nodeIdentifier string) (*Delivery, error) {
+	sender, err := ParseActorAddress(receipt.SenderAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryIdentifier, err := ids.Generate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := m.dataStore.Insert(ctx, &Delivery{
+		Identifier:        deliveryIdentifier,
+		MessageIdentifier: receipt.MessageIdentifier,
+		NodeIdentifier:    nodeIdentifier,
+		SenderAddress:     receipt.SenderAddress,
+		RecipientAddress:  receipt.RecipientAddress,
+		DestinationVertex: sender.Vertex,
+		Kind:              DeliveryKindReceipt,
+		Status:            DeliveryStatusPending,
+		NextAttemptAt:     time.Now().UnixNano(),
+		ReadAt:            receipt.ReadAt,
+		CreatedAt:         time.Now().UnixNano(),
+		UpdatedAt:         time.Now().UnixNano(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.notify()
+
+	return delivery, nil
+}
+
+func (m *DeliveryManager) failed(ctx context.Context, delivery *Delivery) {
+	if delivery.Kind == DeliveryKindReceipt {
+		return
+	}
+
+	err := m.webhookManager.Dispatch(ctx, delivery.SenderAddress, delivery.NodeIdentifier, "", WebhookEventDeliveryFailed, delivery)
+
+	if err != nil {
+		zap.L().Error("error dispatching webhooks", zap.String("delivery", delivery.Identifier), zap.Error(err))
+	}
+}
+
+func (m *DeliveryManager) RecordRead(ctx context.Context, request *ReceiptRequest) error {
+	err := m.dataStore.UpdateReadAtByMessageIdentifierAndSenderAddressAndRecipientAddress(ctx, request.ReadAt, request.MessageIdentifier, request.SenderAddress, request.RecipientAddress, time.Now().UnixNano())
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "delivery of message %s to %s not found", request.MessageIdentifier, request.RecipientAddress)
+	}
+
+	return err
+}
+
+func (m *DeliveryManager) Notifications() <-chan struct{} {
+	return m.notifications
+}
+
+func (m *DeliveryManager) notify() {
+	select {
+	case m.notifications <- struct{}{}:
+	default:
+	}
+}
+
+func (m *DeliveryManager) Recover(ctx context.Context) error {
+	return m.dataStore.UpdateStatusByStatus(ctx, DeliveryStatusPending, DeliveryStatusProcessing, time.Now().UnixNano())
+}
+
+func (m *DeliveryManager) Claim(ctx context.Context) ([][]*Delivery, error) {
+	due, err := m.dataStore.FindDue(ctx, time.Now().UnixNano(), deliveryBatchSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var batches [][]*Delivery
+	batchIndexes := make(map[string]int)
+
+	for _, delivery := range due {
+		err := m.dataStore.UpdateStatusByIdentifierAndStatus(ctx, DeliveryStatusProcessing, delivery.Identifier, DeliveryStatusPending, time.Now().UnixNano())
+
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.Status = DeliveryStatusProcessing
+
+		key := fmt.Sprintf("%s:%s/%s/%s@%s#%d", delivery.Kind, delivery.MessageIdentifier, delivery.OutboxIdentifier, delivery.NodeIdentifier, delivery.DestinationVertex, delivery.Revision)
+		index, ok := batchIndexes[key]
+
+		if !ok {
+			index = len(batches)
+			batchIndexes[key] = index
+			batches = append(batches, nil)
+		}
+
+		batches[index] = append(batches[index], delivery)
+	}
+
+	return batches, nil
+}
+
+func (m *DeliveryManager) Attempt(ctx context.Context, deliveries []*Delivery) {
+	attemptCtx, cancel := context.WithTimeout(ctx, deliveryAttemptTimeout)
+	defer cancel()
+
+	deliveryErrs := m.deliver(attemptCtx, deliveries)
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	vertex := deliveries[0].DestinationVertex
+	delivered, retryable := false, false
+
+	for i, delivery := range deliveries {
+		deliveryErr := deliveryErrs[i]
+		attempts := delivery.Attempts + 1
+
+		if deliveryErr == nil {
+			delivered = true
+
+			err := m.dataStore.UpdateStatusAndAttemptsAndLastErrorAndNextAttemptAtByIdentifier(ctx, DeliveryStatusDelivered, attempts, "", now.UnixNano(), delivery.Identifier, now.UnixNano())
+
+			if err != nil {
+				zap.L().Error("error updating delivery", zap.String("delivery", delivery.Identifier), zap.Error(err))
+			}
+
+			continue
+		}
+
+		zap.L().Warn("delivery attempt failed",
+			zap.String("delivery", delivery.Identifier),
+			zap.String("vertex", delivery.DestinationVertex),
+			zap.Int64("attempts", attempts),
+			zap.Error(deliveryErr))
+
+		status := DeliveryStatusPending
+		nextAttemptAt := now.Add(backoff(attempts)).UnixNano()
+
+		if !isRetryable(deliveryErr) {
+			status = DeliveryStatusRejected
+		} else if attempts >= m.maxAttempts {
+			status = DeliveryStatusDead
+		}
+
+		if isRetryable(deliveryErr) {
+			retryable = true
+		}
+
+		err := m.dataStore.UpdateStatusAndAttemptsAndLastErrorAndNextAttemptAtByIdentifier(ctx, status, attempts, deliveryErr.Error(), nextAttemptAt, delivery.Identifier, now.UnixNano())
+
+		if err != nil {
+			zap.L().Error("error updating delivery", zap.String("delivery", delivery.Identifier), zap.Error(err))
+			continue
+		}
+
+		if status != DeliveryStatusPending {
+			delivery.Status = status
+			delivery.Attempts = attempts
+			delivery.LastError = deliveryErr.Error()
+			delivery.NextAttemptAt = nextAttemptAt
+			delivery.UpdatedAt = now.UnixNano()
+			m.failed(ctx, delivery)
+		}
+	}
+
+	if delivered {
+		err := m.dataStore.DeleteBackoffByVertex(ctx, vertex)
+
+		if err != nil {
+			zap.L().Error("error clearing delivery backoff", zap.String("vertex", vertex), zap.Error(err))
+		}
+	} else if retryable {
+		m.backoffVertex(ctx, vertex, now)
+	}
+}
+
+func (m *DeliveryManager) deliver(ctx context.Context, deliveries []*Delivery) []error {
+	deliveryErrs := make([]error, len(deliveries))
+
+	fail := func(err error) []error {
+		for i := range deliveryErrs {
+			if deliveryErrs[i] == nil {
+				deliveryErrs[i] = err
+			}
+		}
+
+		return deliveryErrs
+	}
+
+	first := deliveries[0]
+
+	if first.Kind == DeliveryKindReceipt {
+		for i, delivery := range deliveries {
+			deliveryErrs[i] = m.deliverReceipt(ctx, delivery)
+		}
+
+		return deliveryErrs
+	}
+
+	message, err := m.messageDataStore.FindByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, first.MessageIdentifier, BoxTypeOutbox, first.OutboxIdentifier, first.NodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fail(fmt.Errorf("message %s not found", first.MessageIdentifier))
+	}
+
+	if err != nil {
+		return fail(err)
+	}
+
+	var allowed []int
+	var recipient *InboxAddress
+	audience := ""
+
+	for i, delivery := range deliveries {
+		deliveryRecipient, err := ParseInboxAddress(delivery.RecipientAddress)
+
+		if err == nil {
+			err = m.policyManager.Check(ctx, deliveryRecipient.Vertex, deliveryRecipient.NodeIdentifier)
+		}
+
+		if err != nil {
+			deliveryErrs[i] = err
+			continue
+		}
+
+		if recipient == nil {
+			recipient = deliveryRecipient
+			audience = recipient.GetNodeAddress()
+		} else if deliveryRecipient.GetNodeAddress() != audience {
+			audience = recipient.Vertex
+		}
+
+		allowed = append(allowed, i)
+	}
+
+	if len(allowed) == 0 {
+		return deliveryErrs
+	}
+
+	sender, err := ParseActorAddress(first.SenderAddress)
+
+	if err != nil {
+		return fail(err)
+	}
+
+	senderNode, err := m.nodeManager.Get(ctx, sender.NodeIdentifier)
+
+	if err != nil {
+		return fail(err)
+	}
+
+	token, err := m.authenticator.GenerateToken(sender.Identifier, senderNode, audience)
+
+	if err != nil {
+		return fail(err)
+	}
+
+	request := newDeliveryRequest(message)
+
+	if len(allowed) == 1 && first.Revision == 0 {
+		delivery := deliveries[allowed[0]]
+		request.RecipientAddress = delivery.RecipientAddress
+		return fail(m.remoteInboxManager.Deliver(ctx, recipient.Vertex, token, delivery.Identifier, request))
+	}
+
+	recipientAddresses := make([]string, 0, len(allowed))
+	deliveryIdentifiers := make([]string, 0, len(allowed))
+
+	for _, i := range allowed {
+		delivery := deliveries[i]
+		recipientAddresses = append(recipientAddresses, delivery.RecipientAddress)
+		deliveryIdentifiers = append(deliveryIdentifiers, delivery.Identifier)
+	}
+
+	slices.Sort(deliveryIdentifiers)
+	idempotencyKey := sha256.Sum256([]byte(strings.Join(deliveryIdentifiers, ",")))
+
+	deliverBatch := m.remoteInboxManager.DeliverBatch
+
+	if first.Revision > 0 {
+		deliverBatch = m.remoteInboxManager.DeliverRevision
+	}
+
+	response, err := deliverBatch(ctx, recipient.Vertex, token, hex.EncodeToString(idempotencyKey[:]), &DeliveryBatchRequest{
+		Message:            request,
+		RecipientAddresses: recipientAddresses,
+	})
+
+	if err != nil {
+		return fail(err)
+	}
+
+	results := make(map[string]*DeliveryResult, len(response.Results))
+
+	for _, result := range response.Results {
+		results[result.RecipientAddress] = result
+	}
+
+	for _, i := range allowed {
+		delivery := deliveries[i]
+		result, ok := results[delivery.RecipientAddress]
+
+		if !ok {
+			deliveryErrs[i] = fmt.Errorf("no delivery result for %s", delivery.RecipientAddress)
+		} else if result.Status != DeliveryStatusDelivered {
+			deliveryErrs[i] = &federation.StatusError{StatusCode: result.StatusCode, Message: result.Error}
+		}
+	}
+
+	return deliveryErrs
+}
+
+func (m *DeliveryManager) deliverReceipt(ctx context.Context, delivery *Delivery) error {
+	sender, err := ParseActorAddress(delivery.SenderAddress)
+
+	if err != nil {
+		return err
+	}
+
+	recipient, err := ParseInboxAddress(delivery.RecipientAddress)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.policyManager.Check(ctx, sender.Vertex, sender.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	recipientNode, err := m.nodeManager.Get(ctx, recipient.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	token, err := m.authenticator.GenerateToken(recipient.ActorIdentifier, recipientNode, sender.GetNodeAddress())
+
+	if err != nil {
+		return err
+	}
+
+	return m.remoteOutboxManager.Receipt(ctx, sender.Vertex, token, &ReceiptRequest{
+		MessageIdentifier: delivery.MessageIdentifier,
+		SenderAddress:     delivery.SenderAddress,
+		RecipientAddress:  delivery.RecipientAddress,
+		ReadAt:            delivery.ReadAt,
+	})
+}
+
+func newDeliveryRequest(message *Message) *DeliveryRequest {
+	return &DeliveryRequest{
+		Identifier:          message.Identifier,
+		SenderAddress:       message.SenderAddress,
+		RecipientAddress:    message.RecipientAddress,
+		RecipientAddresses:  message.RecipientAddresses,
+		ContentType:         message.ContentType,
+		Content:             message.Content,
+		Encryption:          message.Encryption,
+		EncryptionPublicKey: message.EncryptionPublicKey,
+		ThreadIdentifier:    message.ThreadIdentifier,
+		InReplyTo:           message.InReplyTo,
+		Attachments:         message.Attachments,
+		Headers:             message.Headers,
+		Revision:            message.Revision,
+		Retracted:           message.RetractedAt > 0,
+		Signature:           message.Signature,
+		CreatedAt:           message.CreatedAt,
+	}
+}
+
+func (m *DeliveryManager) backoffVertex(ctx context.Context, vertex string, now time.Time) {
+	failures := int64(1)
+
+	existing, err := m.dataStore.FindBackoffByVertex(ctx, vertex)
+
+	if err == nil {
+		failures = existing.Failures + 1
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		zap.L().Error("error reading delivery backoff", zap.String("vertex", vertex), zap.Error(err))
+		return
+	}
+
+	err = m.dataStore.UpsertBackoff(ctx, &DeliveryBackoff{
+		Vertex:        vertex,
+		Failures:      failures,
+		NextAttemptAt: now.Add(backoff(failures)).UnixNano(),
+		UpdatedAt:     now.UnixNano(),
+	})
+
+	if err != nil {
+		zap.L().Error("error updating delivery backoff", zap.String("vertex", vertex), zap.Error(err))
+	}
+}
+
+func (m *DeliveryManager) CancelPending(ctx context.Context, message *Message) error {
+	return m.dataStore.UpdateStatusByMessageIdentifierAndOutboxIdentifierAndNodeIdentifierAndStatus(ctx, DeliveryStatusCancelled, message.Identifier, message.BoxIdentifier, message.NodeIdentifier, DeliveryStatusPending, time.Now().UnixNano())
+}
+
+func (m *DeliveryManager) ListByMessage(ctx context.Context, message *Message) ([]*Delivery, error) {
+	return m.dataStore.FindByMessageIdentifierAndOutboxIdentifierAndNodeIdentifier(ctx, message.Identifier, message.BoxIdentifier, message.NodeIdentifier)
+}
+
+func (m *DeliveryManager) ListDeadLetters(ctx context.Context, pagination *api.Pagination) ([]*Delivery, error) {
+	return m.dataStore.FindByStatusIn(ctx, deadLetterStatuses, pagination)
+}
+
+func (m *DeliveryManager) GetDeadLetter(ctx context.Context, identifier string) (*Delivery, error) {
+	delivery, err := m.dataStore.FindByIdentifierAndStatusIn(ctx, identifier, deadLetterStatuses)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("dead letter %s not found", identifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (m *DeliveryManager) Redrive(ctx context.Context, identifier string) (*Delivery, error) {
+	delivery, err := m.GetDeadLetter(ctx, identifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixNano()
+
+	err = m.dataStore.UpdateStatusAndAttemptsAndLastErrorAndNextAttemptAtByIdentifier(ctx, DeliveryStatusPending, 0, delivery.LastError, now, delivery.Identifier, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.dataStore.DeleteBackoffByVertex(ctx, delivery.DestinationVertex)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Status = DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+
+	m.notify()
+
+	return delivery, nil
+}
+
+func backoff(failures int64) time.Duration {
+	duration := deliveryBaseBackoff
+
+	for i := int64(1); i < failures && duration < deliveryMaxBackoff; i++ {
+		duration *= 2
+	}
+
+	if duration > deliveryMaxBackoff {
+		return deliveryMaxBackoff
+	}
+
+	return duration
+}
+
+func isRetryable(err error) bool {
+	var blockedError *policy.BlockedError
+
+	if errors.As(err, &blockedError) {
+		return false
+	}
+
+	var statusError *federation.StatusError
+
+	if !errors.As(err, &statusError) {
+		return true
+	}
+
+	return statusError.StatusCode >= http.StatusInternalServerError ||
+		statusError.StatusCode == http.StatusRequestTimeout ||
+		statusError.StatusCode == http.StatusTooEarly ||
+		statusError.StatusCode == http.StatusTooManyRequests
+}
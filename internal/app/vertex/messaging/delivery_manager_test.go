@@ -0,0 +1,30 @@
+package messaging
+
+import (
+	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/policy"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
+	"net/http"
+	"testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{err: fmt.Errorf("connection refused"), retryable: true},
+		{err: &federation.StatusError{StatusCode: http.StatusServiceUnavailable}, retryable: true},
+		{err: &federation.StatusError{StatusCode: http.StatusTooManyRequests}, retryable: true},
+		{err: &federation.StatusError{StatusCode: http.StatusTooEarly}, retryable: true},
+		{err: &federation.StatusError{StatusCode: http.StatusNotFound}, retryable: false},
+		{err: &federation.StatusError{StatusCode: http.StatusConflict}, retryable: false},
+		{err: &policy.BlockedError{}, retryable: false},
+	}
+
+	for _, test := range tests {
+		if retryable := isRetryable(test.err); retryable != test.retryable {
+			t.Errorf("isRetryable(%v) = %v, expected %v", test.err, retryable, test.retryable)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const deliveryPollInterval = time.Second
+
+type DeliveryWorker struct {
+	manager     *DeliveryManager
+	concurrency int
+}
+
+func NewDeliveryWorker(manager *DeliveryManager, concurrency int) *DeliveryWorker {
+	return &DeliveryWorker{manager: manager, concurrency: concurrency}
+}
+
+func (w *DeliveryWorker) Run(ctx context.Context) {
+	err := w.manager.Recover(ctx)
+
+	if err != nil {
+		zap.L().Error("error recovering deliveries", zap.Error(err))
+	}
+
+	queue := make(chan []*Delivery)
+	var wg sync.WaitGroup
+
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range queue {
+				w.manager.Attempt(ctx, batch)
+			}
+		}()
+	}
+
+	defer func() {
+		close(queue)
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(deliveryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.manager.Notifications():
+		}
+
+		batches, err := w.manager.Claim(ctx)
+
+		if err != nil {
+			zap.L().Error("error claiming deliveries", zap.Error(err))
+			continue
+		}
+
+		for _, batch := range batches {
+			select {
+			case queue <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
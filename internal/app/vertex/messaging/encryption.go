@@ -0,0 +1,43 @@
+package messaging
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/keys"
+)
+
+const (
+	EncryptionX25519 = "x25519"
+)
+
+func validateEncryption(encryption string, encryptionPublicKey string, content string, recipients int) error {
+	if encryption == "" {
+		if encryptionPublicKey != "" {
+			return fmt.Errorf("encryption public key given for an unencrypted message")
+		}
+
+		return nil
+	}
+
+	if encryption != EncryptionX25519 {
+		return fmt.Errorf("unsupported encryption %s", encryption)
+	}
+
+	if recipients > 1 {
+		return fmt.Errorf("encrypted messages can only be sent to a single recipient")
+	}
+
+	_, err := keys.ConvertX25519PublicKeyFromString(encryptionPublicKey)
+
+	if err != nil {
+		return fmt.Errorf("invalid encryption public key")
+	}
+
+	_, err = base64.StdEncoding.DecodeString(content)
+
+	if err != nil {
+		return fmt.Errorf("encrypted content must be base64 encoded")
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package messaging
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/node"
+	"github.com/evernetproto/evernet/internal/pkg/keys"
+	"net/http"
+)
+
+type EnvelopeManager struct {
+	vertex            string
+	nodeManager       *node.Manager
+	remoteNodeManager *node.RemoteManager
+}
+
+func NewEnvelopeManager(vertex string, nodeManager *node.Manager, remoteNodeManager *node.RemoteManager) *EnvelopeManager {
+	return &EnvelopeManager{vertex: vertex, nodeManager: nodeManager, remoteNodeManager: remoteNodeManager}
+}
+
+func (m *EnvelopeManager) Sign(ctx context.Context, message *Message) (string, error) {
+	sender, err := ParseActorAddress(message.SenderAddress)
+
+	if err != nil {
+		return "", err
+	}
+
+	if sender.Vertex != m.vertex {
+		return "", fmt.Errorf("sender node %s/%s is not hosted on this vertex", sender.Vertex, sender.NodeIdentifier)
+	}
+
+	senderNode, err := m.nodeManager.Get(ctx, sender.NodeIdentifier)
+
+	if err != nil {
+		return "", err
+	}
+
+	signingPrivateKey, err := senderNode.GetSigningPrivateKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	data, err := message.GetEnvelope().Canonicalize()
+
+	if err != nil {
+		return "", err
+	}
+
+	return keys.SignED25519(signingPrivateKey, data), nil
+}
+
+func (m *EnvelopeManager) Verify(ctx context.Context, message *Message) error {
+	if message.Signature == "" {
+		return reject(http.StatusForbidden, "message %s is not signed", message.Identifier)
+	}
+
+	sender, err := ParseActorAddress(message.SenderAddress)
+
+	if err != nil {
+		return reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	data, err := message.GetEnvelope().Canonicalize()
+
+	if err != nil {
+		return err
+	}
+
+	signingPublicKey, err := m.getSigningPublicKey(ctx, sender, false)
+
+	if err != nil {
+		return err
+	}
+
+	if keys.VerifyED25519(signingPublicKey, data, message.Signature) {
+		return nil
+	}
+
+	if sender.Vertex != m.vertex {
+		signingPublicKey, err = m.getSigningPublicKey(ctx, sender, true)
+
+		if err != nil {
+			return err
+		}
+
+		if keys.VerifyED25519(signingPublicKey, data, message.Signature) {
+			return nil
+		}
+	}
+
+	return reject(http.StatusForbidden, "invalid signature for message %s", message.Identifier)
+}
+
+func (m *EnvelopeManager) getSigningPublicKey(ctx context.Context, sender *ActorAddress, refresh bool) (ed25519.PublicKey, error) {
+	if sender.Vertex == m.vertex {
+		senderNode, err := m.nodeManager.Get(ctx, sender.NodeIdentifier)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return senderNode.GetSigningPublicKey()
+	}
+
+	getRemoteNode := m.remoteNodeManager.Get
+
+	if refresh {
+		getRemoteNode = m.remoteNodeManager.Refresh
+	}
+
+	senderNode, err := getRemoteNode(ctx, sender.Vertex, sender.NodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return senderNode.GetSigningPublicKey()
+}
@@ -0,0 +1,34 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	errMessageDropped   = errors.New("message dropped by inbox rule")
+	errMessageDuplicate = errors.New("message already delivered")
+)
+
+type RejectionError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *RejectionError) Error() string {
+	return e.Message
+}
+
+func reject(statusCode int, format string, args ...interface{}) error {
+	return &RejectionError{StatusCode: statusCode, Message: fmt.Sprintf(format, args...)}
+}
+
+func rejectionStatusCode(err error, def int) int {
+	var rejection *RejectionError
+
+	if errors.As(err, &rejection) {
+		return rejection.StatusCode
+	}
+
+	return def
+}
@@ -0,0 +1,118 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"go.uber.org/zap"
+	"strings"
+)
+
+type InboxAccessDataStore struct {
+	db *sql.DB
+}
+
+func NewInboxAccessDataStore(db *sql.DB) *InboxAccessDataStore {
+	return &InboxAccessDataStore{db: db}
+}
+
+func (d *InboxAccessDataStore) Insert(ctx context.Context, entry *InboxAccessEntry) (*InboxAccessEntry, error) {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO inbox_access_entries (inbox_identifier, node_identifier, actor_address, list, entry, created_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (inbox_identifier, node_identifier, list, entry) DO NOTHING",
+		entry.InboxIdentifier,
+		entry.NodeIdentifier,
+		entry.ActorAddress,
+		entry.List,
+		entry.Entry,
+		entry.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (d *InboxAccessDataStore) FindByInboxIdentifierAndNodeIdentifierAndList(ctx context.Context, inboxIdentifier string, nodeIdentifier string, list string) ([]*InboxAccessEntry, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT inbox_identifier, node_identifier, actor_address, list, entry, created_at FROM inbox_access_entries WHERE inbox_identifier = ? AND node_identifier = ? AND list = ? ORDER BY entry",
+		inboxIdentifier, nodeIdentifier, list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var entries []*InboxAccessEntry
+
+	for rows.Next() {
+		var entry InboxAccessEntry
+		err = rows.Scan(&entry.InboxIdentifier, &entry.NodeIdentifier, &entry.ActorAddress, &entry.List, &entry.Entry, &entry.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (d *InboxAccessDataStore) ExistsByInboxIdentifierAndNodeIdentifierAndListAndEntries(ctx context.Context, inboxIdentifier string, nodeIdentifier string, list string, entries ...string) (bool, error) {
+	var count int64
+
+	args := []interface{}{inboxIdentifier, nodeIdentifier, list}
+
+	for _, entry := range entries {
+		args = append(args, entry)
+	}
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM inbox_access_entries WHERE inbox_identifier = ? AND node_identifier = ? AND list = ? AND entry IN (?"+strings.Repeat(", ?", len(entries)-1)+")",
+		args...).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *InboxAccessDataStore) DeleteByInboxIdentifierAndNodeIdentifierAndListAndEntry(ctx context.Context, inboxIdentifier string, nodeIdentifier string, list string, entry string) error {
+	result, err := d.db.ExecContext(ctx,
+		"DELETE FROM inbox_access_entries WHERE inbox_identifier = ? AND node_identifier = ? AND list = ? AND entry = ?",
+		inboxIdentifier, nodeIdentifier, list, entry)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *InboxAccessDataStore) DeleteByInboxIdentifierAndNodeIdentifier(ctx context.Context, inboxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM inbox_access_entries WHERE inbox_identifier = ? AND node_identifier = ?",
+		inboxIdentifier, nodeIdentifier)
+
+	return err
+}
@@ -0,0 +1,133 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type InboxAccessHandler struct {
+	router        *gin.Engine
+	authenticator *actor.Authenticator
+	manager       *InboxAccessManager
+}
+
+func NewInboxAccessHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *InboxAccessManager) *InboxAccessHandler {
+	return &InboxAccessHandler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *InboxAccessHandler) Register() {
+
+	h.router.PUT("/api/v1/messaging/inboxes/:inboxIdentifier/policy", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		var request InboxPolicyUpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.UpdatePolicy(ctx, identifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "inbox policy updated successfully")
+	})
+
+	h.registerList("allowlist", InboxAccessListAllow)
+	h.registerList("blocklist", InboxAccessListBlock)
+}
+
+func (h *InboxAccessHandler) registerList(name string, list string) {
+
+	h.router.POST("/api/v1/messaging/inboxes/:inboxIdentifier/"+name, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		var request InboxAccessEntryRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		entry, err := h.manager.AddEntry(ctx, list, identifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, entry)
+	})
+
+	h.router.GET("/api/v1/messaging/inboxes/:inboxIdentifier/"+name, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+
+		entries, err := h.manager.ListEntries(ctx, list, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	})
+
+	h.router.DELETE("/api/v1/messaging/inboxes/:inboxIdentifier/"+name, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		entry := c.Query("entry")
+
+		if entry == "" {
+			api.ErrorMessage(c, http.StatusBadRequest, "entry is required")
+			return
+		}
+
+		err = h.manager.RemoveEntry(ctx, list, identifier, entry, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "access entry removed successfully")
+	})
+}
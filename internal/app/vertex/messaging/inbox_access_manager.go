@@ -0,0 +1,171 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/evernetproto/evernet/internal/app/vertex/policy"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type InboxAccessManager struct {
+	dataStore        *InboxAccessDataStore
+	inboxDataStore   *InboxDataStore
+	messageDataStore *MessageDataStore
+}
+
+func NewInboxAccessManager(dataStore *InboxAccessDataStore, inboxDataStore *InboxDataStore, messageDataStore *MessageDataStore) *InboxAccessManager {
+	return &InboxAccessManager{dataStore: dataStore, inboxDataStore: inboxDataStore, messageDataStore: messageDataStore}
+}
+
+func (m *InboxAccessManager) UpdatePolicy(ctx context.Context, identifier string, request *InboxPolicyUpdateRequest, actorAddress string, nodeIdentifier string) error {
+	if !isValidInboxPolicy(request.Policy) {
+		return reject(http.StatusBadRequest, "invalid inbox policy %s", request.Policy)
+	}
+
+	err := m.inboxDataStore.UpdatePolicyByIdentifierAndActorAddressAndNodeIdentifier(ctx, request.Policy, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "inbox %s not found", identifier)
+	}
+
+	return err
+}
+
+func (m *InboxAccessManager) AddEntry(ctx context.Context, list string, identifier string, request *InboxAccessEntryRequest, actorAddress string, nodeIdentifier string) (*InboxAccessEntry, error) {
+	err := m.ensureInbox(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !isValidAccessEntry(request.Entry) {
+		return nil, reject(http.StatusBadRequest, "invalid access entry %s", request.Entry)
+	}
+
+	return m.dataStore.Insert(ctx, &InboxAccessEntry{
+		InboxIdentifier: identifier,
+		NodeIdentifier:  nodeIdentifier,
+		ActorAddress:    actorAddress,
+		List:            list,
+		Entry:           normalizeAccessEntry(request.Entry),
+		CreatedAt:       time.Now().UnixNano(),
+	})
+}
+
+func (m *InboxAccessManager) ListEntries(ctx context.Context, list string, identifier string, actorAddress string, nodeIdentifier string) ([]*InboxAccessEntry, error) {
+	err := m.ensureInbox(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dataStore.FindByInboxIdentifierAndNodeIdentifierAndList(ctx, identifier, nodeIdentifier, list)
+}
+
+func (m *InboxAccessManager) RemoveEntry(ctx context.Context, list string, identifier string, entry string, actorAddress string, nodeIdentifier string) error {
+	err := m.ensureInbox(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.dataStore.DeleteByInboxIdentifierAndNodeIdentifierAndListAndEntry(ctx, identifier, nodeIdentifier, list, normalizeAccessEntry(entry))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "access entry %s not found", entry)
+	}
+
+	return err
+}
+
+func (m *InboxAccessManager) Release(ctx context.Context, identifier string, nodeIdentifier string) error {
+	return m.dataStore.DeleteByInboxIdentifierAndNodeIdentifier(ctx, identifier, nodeIdentifier)
+}
+
+func (m *InboxAccessManager) Authorize(ctx context.Context, inbox *Inbox, senderAddress string) error {
+	if senderAddress == inbox.ActorAddress {
+		return nil
+	}
+
+	sender, err := ParseActorAddress(senderAddress)
+
+	if err != nil {
+		return reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	entries := []string{normalizeAccessEntry(sender.String()), normalizeAccessEntry(sender.GetNodeAddress()), normalizeAccessEntry(sender.Vertex)}
+
+	blocked, err := m.dataStore.ExistsByInboxIdentifierAndNodeIdentifierAndListAndEntries(ctx, inbox.Identifier, inbox.NodeIdentifier, InboxAccessListBlock, entries...)
+
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return reject(http.StatusForbidden, "sender %s is not allowed to deliver to inbox %s", senderAddress, inbox.Identifier)
+	}
+
+	if inbox.Policy == InboxPolicyOpen || inbox.Policy == "" {
+		return nil
+	}
+
+	allowed, err := m.dataStore.ExistsByInboxIdentifierAndNodeIdentifierAndListAndEntries(ctx, inbox.Identifier, inbox.NodeIdentifier, InboxAccessListAllow, entries...)
+
+	if err != nil {
+		return err
+	}
+
+	if !allowed && inbox.Policy == InboxPolicyContacts {
+		allowed, err = m.messageDataStore.ExistsOutboxByActorAddressAndNodeIdentifierAndRecipientActorAddress(ctx, inbox.ActorAddress, inbox.NodeIdentifier, sender.String())
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if !allowed {
+		return reject(http.StatusForbidden, "sender %s is not allowed to deliver to inbox %s", senderAddress, inbox.Identifier)
+	}
+
+	return nil
+}
+
+func (m *InboxAccessManager) ensureInbox(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
+	_, err := m.inboxDataStore.FindByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "inbox %s not found", identifier)
+	}
+
+	return err
+}
+
+func isValidAccessEntry(entry string) bool {
+	components := strings.Split(entry, "/")
+
+	if len(components) > 3 {
+		return false
+	}
+
+	for _, component := range components {
+		if component == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func normalizeAccessEntry(entry string) string {
+	vertex, rest, ok := strings.Cut(entry, "/")
+	vertex = policy.NormalizeVertex(vertex)
+
+	if !ok {
+		return vertex
+	}
+
+	return vertex + "/" + rest
+}
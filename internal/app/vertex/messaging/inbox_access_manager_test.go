@@ -0,0 +1,132 @@
+package messaging
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsValidAccessEntry(t *testing.T) {
+	tests := []struct {
+		entry string
+		valid bool
+	}{
+		{"vertex.example", true},
+		{"vertex.example/node", true},
+		{"vertex.example/node/alice", true},
+		{"vertex.example/node/alice/inbox", false},
+		{"", false},
+		{"vertex.example//alice", false},
+		{"vertex.example/", false},
+		{"/node", false},
+	}
+
+	for _, test := range tests {
+		if got := isValidAccessEntry(test.entry); got != test.valid {
+			t.Errorf("isValidAccessEntry(%q) = %v, expected %v", test.entry, got, test.valid)
+		}
+	}
+}
+
+func TestInboxAccessManagerAuthorize(t *testing.T) {
+	ctx := context.Background()
+	database := newTestDatabase(t)
+	inboxDataStore := NewInboxDataStore(database)
+	manager := NewInboxAccessManager(NewInboxAccessDataStore(database), inboxDataStore, NewMessageDataStore(database))
+
+	owner := "local.example/node/owner"
+
+	inbox, err := inboxDataStore.Insert(ctx, &Inbox{
+		Identifier:     "main",
+		DisplayName:    "Main",
+		NodeIdentifier: "node",
+		ActorAddress:   owner,
+		Policy:         InboxPolicyOpen,
+		CreatedAt:      time.Now().UnixNano(),
+		UpdatedAt:      time.Now().UnixNano(),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addEntry := func(list string, entry string) {
+		t.Helper()
+
+		_, err := manager.AddEntry(ctx, list, inbox.Identifier, &InboxAccessEntryRequest{Entry: entry}, owner, inbox.NodeIdentifier)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect := func(senderAddress string, status int) {
+		t.Helper()
+
+		err := manager.Authorize(ctx, inbox, senderAddress)
+
+		if status == 0 {
+			if err != nil {
+				t.Fatalf("expected %s to be authorized, got %v", senderAddress, err)
+			}
+
+			return
+		}
+
+		if got := rejectionStatusCode(err, 0); got != status {
+			t.Fatalf("expected %s to be rejected with %d, got %v", senderAddress, status, err)
+		}
+	}
+
+	expect("remote.example/node/alice", 0)
+
+	addEntry(InboxAccessListBlock, "remote.example/node/alice")
+	addEntry(InboxAccessListBlock, "spam.example")
+
+	expect("remote.example/node/alice", http.StatusForbidden)
+	expect("spam.example/any/sender", http.StatusForbidden)
+	expect("remote.example/node/bob", 0)
+	expect(owner, 0)
+
+	addEntry(InboxAccessListBlock, "Mixed.Example:443")
+
+	expect("mixed.example/node/alice", http.StatusForbidden)
+	expect("MIXED.example:443/node/alice", http.StatusForbidden)
+
+	err = manager.RemoveEntry(ctx, InboxAccessListBlock, inbox.Identifier, "mixed.EXAMPLE", owner, inbox.NodeIdentifier)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect("mixed.example/node/alice", 0)
+
+	inbox.Policy = InboxPolicyAllowlist
+
+	expect("remote.example/node/bob", http.StatusForbidden)
+
+	addEntry(InboxAccessListAllow, "remote.example/node")
+
+	expect("remote.example/node/bob", 0)
+	expect("remote.example/node/alice", http.StatusForbidden)
+	expect("remote.example/other/carol", http.StatusForbidden)
+	expect("not-an-address", http.StatusBadRequest)
+
+	inbox.Policy = InboxPolicyContacts
+
+	expect("remote.example/other/carol", http.StatusForbidden)
+	expect("remote.example/node/bob", 0)
+
+	_, err = manager.AddEntry(ctx, InboxAccessListAllow, inbox.Identifier, &InboxAccessEntryRequest{Entry: "a/b/c/d"}, owner, inbox.NodeIdentifier)
+
+	if got := rejectionStatusCode(err, 0); got != http.StatusBadRequest {
+		t.Fatalf("expected invalid entry to be rejected with 400, got %v", err)
+	}
+
+	_, err = manager.AddEntry(ctx, InboxAccessListAllow, "missing", &InboxAccessEntryRequest{Entry: "remote.example"}, owner, inbox.NodeIdentifier)
+
+	if got := rejectionStatusCode(err, 0); got != http.StatusNotFound {
+		t.Fatalf("expected missing inbox to be rejected with 404, got %v", err)
+	}
+}
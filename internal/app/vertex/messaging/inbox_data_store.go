@@ -3,6 +3,7 @@ package messaging
 import (
 	"context"
 	"database/sql"
+	"github.com/evernetproto/evernet/internal/pkg/api"
 	"go.uber.org/zap"
 )
 
@@ -15,11 +16,13 @@ func NewInboxDataStore(db *sql.DB) *InboxDataStore {
 }
 
 func (d *InboxDataStore) Insert(ctx context.Context, inbox *Inbox) (*Inbox, error) {
-	_, err := d.db.ExecContext(ctx, "INSERT INTO inboxes (identifier, display_name, node_identifier, actor_address, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+	_, err := d.db.ExecContext(ctx, "INSERT INTO inboxes (identifier, display_name, node_identifier, actor_address, read_receipts, policy, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
 		inbox.Identifier,
 		inbox.DisplayName,
 		inbox.NodeIdentifier,
 		inbox.ActorAddress,
+		inbox.ReadReceipts,
+		inbox.Policy,
 		inbox.CreatedAt,
 		inbox.UpdatedAt)
 
@@ -30,9 +33,10 @@ func (d *InboxDataStore) Insert(ctx context.Context, inbox *Inbox) (*Inbox, erro
 	return inbox, nil
 }
 
-func (d *InboxDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Context, actorAddress string, nodeIdentifier string, page int64, size int64) ([]*Inbox, error) {
-	rows, err := d.db.QueryContext(ctx, "SELECT identifier, display_name, node_identifier, actor_address, created_at, updated_at FROM inboxes WHERE actor_address = ? AND node_identifier = ? LIMIT ? OFFSET ?",
-		actorAddress, nodeIdentifier, size, page*size)
+func (d *InboxDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Context, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Inbox, error) {
+	createdAt, identifier := pagination.Bounds(false)
+	rows, err := d.db.QueryContext(ctx, "SELECT identifier, display_name, node_identifier, actor_address, read_receipts, policy, retention_max_age, retention_max_count, created_at, updated_at FROM inboxes WHERE actor_address = ? AND node_identifier = ? AND (created_at, identifier) > (?, ?) ORDER BY created_at, identifier LIMIT ? OFFSET ?",
+		actorAddress, nodeIdentifier, createdAt, identifier, pagination.Size, pagination.Offset())
 
 	if err != nil {
 		return nil, err
@@ -49,7 +53,7 @@ func (d *InboxDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Context
 
 	for rows.Next() {
 		var inbox Inbox
-		err = rows.Scan(&inbox.Identifier, &inbox.DisplayName, &inbox.NodeIdentifier, &inbox.ActorAddress, &inbox.CreatedAt, &inbox.UpdatedAt)
+		err = rows.Scan(&inbox.Identifier, &inbox.DisplayName, &inbox.NodeIdentifier, &inbox.ActorAddress, &inbox.ReadReceipts, &inbox.Policy, &inbox.RetentionMaxAge, &inbox.RetentionMaxCount, &inbox.CreatedAt, &inbox.UpdatedAt)
 
 		if err != nil {
 			return nil, err
@@ -68,12 +72,16 @@ func (d *InboxDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Context
 func (d *InboxDataStore) FindByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*Inbox, error) {
 	var inbox Inbox
 	err := d.db.QueryRowContext(ctx,
-		"SELECT identifier, display_name, node_identifier, actor_address, created_at, updated_at FROM inboxes WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		"SELECT identifier, display_name, node_identifier, actor_address, read_receipts, policy, retention_max_age, retention_max_count, created_at, updated_at FROM inboxes WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
 		identifier, actorAddress, nodeIdentifier).Scan(
 		&inbox.Identifier,
 		&inbox.DisplayName,
 		&inbox.NodeIdentifier,
 		&inbox.ActorAddress,
+		&inbox.ReadReceipts,
+		&inbox.Policy,
+		&inbox.RetentionMaxAge,
+		&inbox.RetentionMaxCount,
 		&inbox.CreatedAt,
 		&inbox.UpdatedAt)
 
@@ -105,6 +113,27 @@ func (d *InboxDataStore) UpdateDisplayNameByIdentifierAndActorAddressAndNodeIden
 	return nil
 }
 
+func (d *InboxDataStore) UpdateReadReceiptsByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, readReceipts bool, identifier string, actorAddress string, nodeIdentifier string) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE inboxes SET read_receipts = ? WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		readReceipts, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 func (d *InboxDataStore) DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
 	result, err := d.db.ExecContext(ctx,
 		"DELETE FROM inboxes WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
@@ -137,3 +166,80 @@ func (d *InboxDataStore) ExistsByIdentifierAndNodeIdentifier(ctx context.Context
 
 	return count > 0, nil
 }
+
+func (d *InboxDataStore) UpdatePolicyByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, policy string, identifier string, actorAddress string, nodeIdentifier string) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE inboxes SET policy = ? WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		policy, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *InboxDataStore) UpdateRetentionByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, retentionMaxAge int64, retentionMaxCount int64, identifier string, actorAddress string, nodeIdentifier string) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE inboxes SET retention_max_age = ?, retention_max_count = ? WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		retentionMaxAge, retentionMaxCount, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *InboxDataStore) FindAllWithEffectiveRetention(ctx context.Context) ([]*Inbox, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT i.identifier, i.display_name, i.node_identifier, i.actor_address, i.read_receipts, i.policy, CASE WHEN i.retention_max_age > 0 THEN i.retention_max_age ELSE n.retention_max_age END, CASE WHEN i.retention_max_count > 0 THEN i.retention_max_count ELSE n.retention_max_count END, i.created_at, i.updated_at FROM inboxes i JOIN nodes n ON n.identifier = i.node_identifier WHERE i.retention_max_age > 0 OR i.retention_max_count > 0 OR n.retention_max_age > 0 OR n.retention_max_count > 0")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var inboxes []*Inbox
+
+	for rows.Next() {
+		var inbox Inbox
+		err = rows.Scan(&inbox.Identifier, &inbox.DisplayName, &inbox.NodeIdentifier, &inbox.ActorAddress, &inbox.ReadReceipts, &inbox.Policy, &inbox.RetentionMaxAge, &inbox.RetentionMaxCount, &inbox.CreatedAt, &inbox.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		inboxes = append(inboxes, &inbox)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return inboxes, nil
+}
@@ -0,0 +1,93 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"go.uber.org/zap"
+)
+
+type InboxEventDataStore struct {
+	db *sql.DB
+}
+
+func NewInboxEventDataStore(db *sql.DB) *InboxEventDataStore {
+	return &InboxEventDataStore{db: db}
+}
+
+func (d *InboxEventDataStore) Insert(ctx context.Context, event *InboxEvent) (*InboxEvent, error) {
+	result, err := d.db.ExecContext(ctx,
+		"INSERT INTO inbox_events (box_type, inbox_identifier, node_identifier, type, message_identifier, data, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		event.BoxType,
+		event.InboxIdentifier,
+		event.NodeIdentifier,
+		event.Type,
+		event.MessageIdentifier,
+		event.Data,
+		event.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sequence, err := result.LastInsertId()
+
+	if err != nil {
+		return nil, err
+	}
+
+	event.Sequence = sequence
+
+	return event, nil
+}
+
+func (d *InboxEventDataStore) FindByBoxTypeAndInboxIdentifierAndNodeIdentifierAndSequenceAfter(ctx context.Context, boxType string, inboxIdentifier string, nodeIdentifier string, sequence int64, size int64) ([]*InboxEvent, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT sequence, box_type, inbox_identifier, node_identifier, type, message_identifier, data, created_at FROM inbox_events WHERE box_type = ? AND inbox_identifier = ? AND node_identifier = ? AND sequence > ? ORDER BY sequence LIMIT ?",
+		boxType, inboxIdentifier, nodeIdentifier, sequence, size)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var events []*InboxEvent
+
+	for rows.Next() {
+		var event InboxEvent
+		err = rows.Scan(&event.Sequence, &event.BoxType, &event.InboxIdentifier, &event.NodeIdentifier, &event.Type, &event.MessageIdentifier, &event.Data, &event.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (d *InboxEventDataStore) DeleteByBoxTypeAndInboxIdentifierAndNodeIdentifier(ctx context.Context, boxType string, inboxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM inbox_events WHERE box_type = ? AND inbox_identifier = ? AND node_identifier = ?",
+		boxType, inboxIdentifier, nodeIdentifier)
+
+	return err
+}
+
+func (d *InboxEventDataStore) DeleteByBoxTypeAndInboxIdentifierAndNodeIdentifierAndMessageIdentifier(ctx context.Context, boxType string, inboxIdentifier string, nodeIdentifier string, messageIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM inbox_events WHERE box_type = ? AND inbox_identifier = ? AND node_identifier = ? AND message_identifier = ?",
+		boxType, inboxIdentifier, nodeIdentifier, messageIdentifier)
+
+	return err
+}
@@ -0,0 +1,198 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const inboxSubscriptionBufferSize = 64
+
+type InboxSubscription struct {
+	key     string
+	events  chan *InboxEvent
+	manager *InboxEventManager
+}
+
+func (s *InboxSubscription) Events() <-chan *InboxEvent {
+	return s.events
+}
+
+func (s *InboxSubscription) Close() {
+	s.manager.mutex.Lock()
+	defer s.manager.mutex.Unlock()
+
+	s.manager.remove(s)
+}
+
+type InboxEventManager struct {
+	dataStore     *InboxEventDataStore
+	mutex         sync.Mutex
+	subscriptions map[string]map[*InboxSubscription]struct{}
+	closed        bool
+}
+
+func NewInboxEventManager(dataStore *InboxEventDataStore) *InboxEventManager {
+	return &InboxEventManager{
+		dataStore:     dataStore,
+		subscriptions: make(map[string]map[*InboxSubscription]struct{}),
+	}
+}
+
+func (m *InboxEventManager) Record(ctx context.Context, inbox *Inbox, eventType string, message *Message) (*InboxEvent, error) {
+	event, err := m.insert(ctx, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier, eventType, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.publish(event)
+
+	return event, nil
+}
+
+func (m *InboxEventManager) Tombstone(ctx context.Context, inbox *Inbox, message *Message) (*InboxEvent, error) {
+	err := m.dataStore.DeleteByBoxTypeAndInboxIdentifierAndNodeIdentifierAndMessageIdentifier(ctx, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier, message.Identifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Record(ctx, inbox, InboxEventTypeDeletion, tombstone(message))
+}
+
+func (m *InboxEventManager) TombstoneOutbox(ctx context.Context, outbox *Outbox, message *Message) (*InboxEvent, error) {
+	return m.insert(ctx, BoxTypeOutbox, outbox.Identifier, outbox.NodeIdentifier, InboxEventTypeDeletion, tombstone(message))
+}
+
+func (m *InboxEventManager) Retract(ctx context.Context, inbox *Inbox, message *Message) (*InboxEvent, error) {
+	err := m.dataStore.DeleteByBoxTypeAndInboxIdentifierAndNodeIdentifierAndMessageIdentifier(ctx, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier, message.Identifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Record(ctx, inbox, InboxEventTypeRetraction, message)
+}
+
+func (m *InboxEventManager) ListAfter(ctx context.Context, inbox *Inbox, sequence int64, size int64) ([]*InboxEvent, error) {
+	return m.dataStore.FindByBoxTypeAndInboxIdentifierAndNodeIdentifierAndSequenceAfter(ctx, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier, sequence, size)
+}
+
+func (m *InboxEventManager) Delete(ctx context.Context, inbox *Inbox) error {
+	return m.dataStore.DeleteByBoxTypeAndInboxIdentifierAndNodeIdentifier(ctx, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier)
+}
+
+func (m *InboxEventManager) DeleteOutbox(ctx context.Context, outboxIdentifier string, nodeIdentifier string) error {
+	return m.dataStore.DeleteByBoxTypeAndInboxIdentifierAndNodeIdentifier(ctx, BoxTypeOutbox, outboxIdentifier, nodeIdentifier)
+}
+
+func (m *InboxEventManager) insert(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string, eventType string, message *Message) (*InboxEvent, error) {
+	data, err := json.Marshal(message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dataStore.Insert(ctx, &InboxEvent{
+		BoxType:           boxType,
+		InboxIdentifier:   boxIdentifier,
+		NodeIdentifier:    nodeIdentifier,
+		Type:              eventType,
+		MessageIdentifier: message.Identifier,
+		Data:              string(data),
+		CreatedAt:         time.Now().UnixNano(),
+	})
+}
+
+func tombstone(message *Message) *Message {
+	return &Message{
+		Identifier:       message.Identifier,
+		BoxType:          message.BoxType,
+		BoxIdentifier:    message.BoxIdentifier,
+		NodeIdentifier:   message.NodeIdentifier,
+		ActorAddress:     message.ActorAddress,
+		SenderAddress:    message.SenderAddress,
+		RecipientAddress: message.RecipientAddress,
+		ThreadIdentifier: message.ThreadIdentifier,
+		CreatedAt:        message.CreatedAt,
+		UpdatedAt:        time.Now().UnixNano(),
+	}
+}
+
+func (m *InboxEventManager) Subscribe(inbox *Inbox) *InboxSubscription {
+	subscription := &InboxSubscription{
+		key:     subscriptionKey(inbox.NodeIdentifier, inbox.Identifier),
+		events:  make(chan *InboxEvent, inboxSubscriptionBufferSize),
+		manager: m,
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		close(subscription.events)
+		return subscription
+	}
+
+	if _, ok := m.subscriptions[subscription.key]; !ok {
+		m.subscriptions[subscription.key] = make(map[*InboxSubscription]struct{})
+	}
+
+	m.subscriptions[subscription.key][subscription] = struct{}{}
+
+	return subscription
+}
+
+func (m *InboxEventManager) Run(ctx context.Context) {
+	<-ctx.Done()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.closed = true
+
+	for _, subscriptions := range m.subscriptions {
+		for subscription := range subscriptions {
+			m.remove(subscription)
+		}
+	}
+}
+
+func (m *InboxEventManager) publish(event *InboxEvent) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for subscription := range m.subscriptions[subscriptionKey(event.NodeIdentifier, event.InboxIdentifier)] {
+		select {
+		case subscription.events <- event:
+		default:
+			m.remove(subscription)
+		}
+	}
+}
+
+func (m *InboxEventManager) remove(subscription *InboxSubscription) {
+	subscriptions, ok := m.subscriptions[subscription.key]
+
+	if !ok {
+		return
+	}
+
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(subscriptions, subscription)
+	close(subscription.events)
+
+	if len(subscriptions) == 0 {
+		delete(m.subscriptions, subscription.key)
+	}
+}
+
+func subscriptionKey(nodeIdentifier string, inboxIdentifier string) string {
+	return fmt.Sprintf("%s/%s", nodeIdentifier, inboxIdentifier)
+}
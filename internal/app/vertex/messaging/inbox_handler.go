@@ -2,21 +2,32 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/app/vertex/idempotency"
 	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+const (
+	streamReplayBatchSize   = 100
+	streamHeartbeatInterval = 15 * time.Second
+)
+
 type InboxHandler struct {
-	router        *gin.Engine
-	authenticator *actor.Authenticator
-	manager       *InboxManager
+	router             *gin.Engine
+	authenticator      *actor.Authenticator
+	manager            *InboxManager
+	idempotencyManager *idempotency.Manager
 }
 
-func NewInboxHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *InboxManager) *InboxHandler {
-	return &InboxHandler{router: router, authenticator: authenticator, manager: manager}
+func NewInboxHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *InboxManager, idempotencyManager *idempotency.Manager) *InboxHandler {
+	return &InboxHandler{router: router, authenticator: authenticator, manager: manager, idempotencyManager: idempotencyManager}
 }
 
 func (h *InboxHandler) Register() {
@@ -31,6 +42,10 @@ func (h *InboxHandler) Register() {
 			return
 		}
 
+		if h.idempotencyManager.Replay(ctx, c, authenticatedActor.Address) {
+			return
+		}
+
 		var request InboxCreationRequest
 		if err := c.ShouldBindJSON(&request); err != nil {
 			api.Error(c, http.StatusBadRequest, err)
@@ -57,16 +72,22 @@ func (h *InboxHandler) Register() {
 			return
 		}
 
-		page, size := api.Page(c)
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
 
-		inboxes, err := h.manager.List(ctx, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, page, size)
+		inboxes, err := h.manager.List(ctx, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, pagination)
 
 		if err != nil {
 			api.Error(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, inboxes)
+		api.Paginated(c, http.StatusOK, pagination, inboxes, func(inbox *InboxResponse) *api.Cursor {
+			return &api.Cursor{CreatedAt: inbox.CreatedAt, Identifier: inbox.Identifier}
+		})
 	})
 
 	h.router.GET("/api/v1/messaging/inboxes/:inboxIdentifier", func(c *gin.Context) {
@@ -118,6 +139,60 @@ func (h *InboxHandler) Register() {
 		api.Success(c, http.StatusOK, "inbox updated successfully")
 	})
 
+	h.router.PUT("/api/v1/messaging/inboxes/:inboxIdentifier/read-receipts", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		var request InboxReadReceiptsUpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.UpdateReadReceipts(ctx, identifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "inbox read receipts updated successfully")
+	})
+
+	h.router.PUT("/api/v1/messaging/inboxes/:inboxIdentifier/retention", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		var request InboxRetentionUpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.UpdateRetention(ctx, identifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "inbox retention updated successfully")
+	})
+
 	h.router.DELETE("/api/v1/messaging/inboxes/:inboxIdentifier", func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c, 5*time.Second)
 		defer cancel()
@@ -139,4 +214,316 @@ func (h *InboxHandler) Register() {
 
 		api.Success(c, http.StatusOK, "inbox deleted successfully")
 	})
+
+	h.router.GET("/api/v1/messaging/inboxes/:inboxIdentifier/messages", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		messages, err := h.manager.ListMessages(ctx, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, pagination)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Paginated(c, http.StatusOK, pagination, messages, func(message *Message) *api.Cursor {
+			return &api.Cursor{CreatedAt: message.CreatedAt, Identifier: message.Identifier}
+		})
+	})
+
+	h.router.GET("/api/v1/messaging/inboxes/:inboxIdentifier/messages/:messageIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		messageIdentifier := c.Param("messageIdentifier")
+
+		message, err := h.manager.GetMessage(ctx, messageIdentifier, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, message)
+	})
+
+	h.router.PUT("/api/v1/messaging/inboxes/:inboxIdentifier/messages/state", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 10*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		var request MessageStateBatchUpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.UpdateMessageStates(ctx, &request, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "message states updated successfully")
+	})
+
+	h.router.PUT("/api/v1/messaging/inboxes/:inboxIdentifier/messages/:messageIdentifier/state", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		messageIdentifier := c.Param("messageIdentifier")
+		var request MessageStateUpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.UpdateMessageState(ctx, messageIdentifier, &request, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "message state updated successfully")
+	})
+
+	h.router.GET("/api/v1/messaging/inboxes/:inboxIdentifier/messages/:messageIdentifier/verification", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+		messageIdentifier := c.Param("messageIdentifier")
+
+		verification, err := h.manager.VerifyMessage(ctx, messageIdentifier, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, verification)
+	})
+
+	h.router.POST("/api/v1/messaging/deliveries", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		if h.idempotencyManager.Replay(ctx, c, authenticatedActor.Address) {
+			return
+		}
+
+		var request DeliveryRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		_, err = h.manager.Receive(ctx, &request, authenticatedActor.Address, authenticatedActor.TargetNodeAddress)
+
+		if errors.Is(err, errMessageDropped) {
+			c.Status(http.StatusAccepted)
+			return
+		}
+
+		if errors.Is(err, errMessageDuplicate) {
+			api.Success(c, http.StatusOK, "message already delivered")
+			return
+		}
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Success(c, http.StatusCreated, "message delivered successfully")
+	})
+
+	h.router.POST("/api/v1/messaging/deliveries/batch", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateFederatedContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		if h.idempotencyManager.Replay(ctx, c, authenticatedActor.Address) {
+			return
+		}
+
+		var request DeliveryBatchRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		response, err := h.manager.ReceiveBatch(ctx, &request, authenticatedActor.Address, authenticatedActor.TargetNodeAddress)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	})
+
+	h.router.GET("/api/v1/messaging/inboxes/:inboxIdentifier/stream", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("inboxIdentifier")
+
+		inbox, err := h.manager.Get(ctx, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		lastEventIdentifier := c.GetHeader("Last-Event-ID")
+
+		if lastEventIdentifier == "" {
+			lastEventIdentifier = c.DefaultQuery("last_event_id", "0")
+		}
+
+		sequence, err := strconv.ParseInt(lastEventIdentifier, 10, 64)
+
+		if err != nil {
+			api.ErrorMessage(c, http.StatusBadRequest, "invalid last event id")
+			return
+		}
+
+		h.stream(c, inbox, sequence)
+	})
+}
+
+func (h *InboxHandler) stream(c *gin.Context, inbox *Inbox, sequence int64) {
+	ctx := c.Request.Context()
+
+	subscription := h.manager.Subscribe(inbox)
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	write := func(event *InboxEvent) bool {
+		err := sse.Encode(c.Writer, sse.Event{
+			Id:    strconv.FormatInt(event.Sequence, 10),
+			Event: event.Type,
+			Data:  event.Data,
+		})
+
+		if err != nil {
+			return false
+		}
+
+		sequence = event.Sequence
+		return true
+	}
+
+	for {
+		events, err := h.manager.ListEvents(ctx, inbox, sequence, streamReplayBatchSize)
+
+		if err != nil {
+			zap.L().Error("error replaying inbox events", zap.String("inbox", inbox.Identifier), zap.Error(err))
+			return
+		}
+
+		for _, event := range events {
+			if !write(event) {
+				return
+			}
+		}
+
+		if len(events) < streamReplayBatchSize {
+			break
+		}
+	}
+
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+
+			if event.Sequence <= sequence {
+				continue
+			}
+
+			if !write(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		c.Writer.Flush()
+	}
 }
@@ -5,15 +5,38 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"go.uber.org/zap"
+	"net/http"
 	"time"
 )
 
 type InboxManager struct {
-	dataStore *InboxDataStore
+	vertex           string
+	dataStore        *InboxDataStore
+	messageDataStore *MessageDataStore
+	eventManager     *InboxEventManager
+	envelopeManager  *EnvelopeManager
+	receiptManager   *ReceiptManager
+	blobManager      *BlobManager
+	ruleManager      *InboxRuleManager
+	accessManager    *InboxAccessManager
+	webhookManager   *WebhookManager
 }
 
-func NewInboxManager(dataStore *InboxDataStore) *InboxManager {
-	return &InboxManager{dataStore: dataStore}
+func NewInboxManager(vertex string, dataStore *InboxDataStore, messageDataStore *MessageDataStore, eventManager *InboxEventManager, envelopeManager *EnvelopeManager, receiptManager *ReceiptManager, blobManager *BlobManager, ruleManager *InboxRuleManager, accessManager *InboxAccessManager, webhookManager *WebhookManager) *InboxManager {
+	return &InboxManager{
+		vertex:           vertex,
+		dataStore:        dataStore,
+		messageDataStore: messageDataStore,
+		eventManager:     eventManager,
+		envelopeManager:  envelopeManager,
+		receiptManager:   receiptManager,
+		blobManager:      blobManager,
+		ruleManager:      ruleManager,
+		accessManager:    accessManager,
+		webhookManager:   webhookManager,
+	}
 }
 
 func (m *InboxManager) Create(ctx context.Context, request *InboxCreationRequest, actorAddress string, nodeIdentifier string) (*Inbox, error) {
@@ -27,11 +50,23 @@ func (m *InboxManager) Create(ctx context.Context, request *InboxCreationRequest
 		return nil, fmt.Errorf("inbox %s already exists", request.Identifier)
 	}
 
+	policy := request.Policy
+
+	if policy == "" {
+		policy = InboxPolicyOpen
+	}
+
+	if !isValidInboxPolicy(policy) {
+		return nil, fmt.Errorf("invalid inbox policy %s", policy)
+	}
+
 	inbox := &Inbox{
 		Identifier:     request.Identifier,
 		DisplayName:    request.DisplayName,
 		NodeIdentifier: nodeIdentifier,
 		ActorAddress:   actorAddress,
+		ReadReceipts:   request.ReadReceipts,
+		Policy:         policy,
 		CreatedAt:      time.Now().UnixNano(),
 		UpdatedAt:      time.Now().UnixNano(),
 	}
@@ -39,8 +74,26 @@ func (m *InboxManager) Create(ctx context.Context, request *InboxCreationRequest
 	return m.dataStore.Insert(ctx, inbox)
 }
 
-func (m *InboxManager) List(ctx context.Context, actorAddress string, nodeIdentifier string, page int64, size int64) ([]*Inbox, error) {
-	return m.dataStore.FindByActorAddressAndNodeIdentifier(ctx, actorAddress, nodeIdentifier, page, size)
+func (m *InboxManager) List(ctx context.Context, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*InboxResponse, error) {
+	inboxes, err := m.dataStore.FindByActorAddressAndNodeIdentifier(ctx, actorAddress, nodeIdentifier, pagination)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*InboxResponse
+
+	for _, inbox := range inboxes {
+		unreadCount, err := m.messageDataStore.CountByBoxTypeAndBoxIdentifierAndNodeIdentifierAndState(ctx, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier, MessageStateUnread)
+
+		if err != nil {
+			return nil, err
+		}
+
+		responses = append(responses, &InboxResponse{Inbox: inbox, UnreadCount: unreadCount})
+	}
+
+	return responses, nil
 }
 
 func (m *InboxManager) Get(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*Inbox, error) {
@@ -67,12 +120,489 @@ func (m *InboxManager) Update(ctx context.Context, identifier string, request *I
 	return err
 }
 
+func (m *InboxManager) UpdateReadReceipts(ctx context.Context, identifier string, request *InboxReadReceiptsUpdateRequest, actorAddress string, nodeIdentifier string) error {
+	err := m.dataStore.UpdateReadReceiptsByIdentifierAndActorAddressAndNodeIdentifier(ctx, *request.ReadReceipts, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("inbox %s not found", identifier)
+	}
+
+	return err
+}
+
+func (m *InboxManager) UpdateRetention(ctx context.Context, identifier string, request *InboxRetentionUpdateRequest, actorAddress string, nodeIdentifier string) error {
+	err := m.dataStore.UpdateRetentionByIdentifierAndActorAddressAndNodeIdentifier(ctx, request.MaxAge, request.MaxCount, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("inbox %s not found", identifier)
+	}
+
+	return err
+}
+
 func (m *InboxManager) Delete(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
-	err := m.dataStore.DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
+	inbox, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.dataStore.DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("inbox %s not found", identifier)
 	}
 
+	if err != nil {
+		return err
+	}
+
+	err = m.messageDataStore.DeleteByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, BoxTypeInbox, identifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.blobManager.Release(ctx, BoxTypeInbox, identifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.accessManager.Release(ctx, identifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.webhookManager.Release(ctx, identifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	return m.eventManager.Delete(ctx, inbox)
+}
+
+func (m *InboxManager) Deliver(ctx context.Context, message *Message) (*Message, error) {
+	recipient, err := ParseInboxAddress(message.RecipientAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inbox, err := m.dataStore.FindByIdentifierAndActorAddressAndNodeIdentifier(ctx, recipient.InboxIdentifier, recipient.GetActorAddress(), recipient.NodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reject(http.StatusNotFound, "inbox %s not found", recipient.String())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.accessManager.Authorize(ctx, inbox, message.SenderAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outcome, err := m.ruleManager.Evaluate(ctx, inbox, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if outcome.drop {
+		zap.L().Debug("message dropped by inbox rule", zap.String("inbox", inbox.Identifier), zap.String("message", message.Identifier))
+		return nil, errMessageDropped
+	}
+
+	if outcome.targetInboxIdentifier != "" {
+		targetInbox, err := m.dataStore.FindByIdentifierAndActorAddressAndNodeIdentifier(ctx, outcome.targetInboxIdentifier, inbox.ActorAddress, inbox.NodeIdentifier)
+
+		if err != nil {
+			zap.L().Error("error resolving inbox rule target", zap.String("inbox", outcome.targetInboxIdentifier), zap.Error(err))
+		} else {
+			inbox = targetInbox
+		}
+	}
+
+	existingMessage, err := m.messageDataStore.FindByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message.Identifier, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier)
+
+	if err == nil {
+		if !existingMessage.isDuplicateOf(message) {
+			return nil, reject(http.StatusConflict, "message %s already exists with different content", message.Identifier)
+		}
+
+		return existingMessage, errMessageDuplicate
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	threadIdentifier := message.ThreadIdentifier
+
+	if threadIdentifier == "" {
+		threadIdentifier = message.Identifier
+	}
+
+	inboxMessage := &Message{
+		Identifier:          message.Identifier,
+		BoxType:             BoxTypeInbox,
+		BoxIdentifier:       inbox.Identifier,
+		NodeIdentifier:      inbox.NodeIdentifier,
+		ActorAddress:        inbox.ActorAddress,
+		SenderAddress:       message.SenderAddress,
+		RecipientAddress:    message.RecipientAddress,
+		RecipientAddresses:  message.RecipientAddresses,
+		ContentType:         message.ContentType,
+		Content:             message.Content,
+		Encryption:          message.Encryption,
+		EncryptionPublicKey: message.EncryptionPublicKey,
+		Signature:           message.Signature,
+		State:               MessageStateUnread,
+		ThreadIdentifier:    threadIdentifier,
+		InReplyTo:           message.InReplyTo,
+		Attachments:         message.Attachments,
+		Headers:             message.Headers,
+		Labels:              outcome.labels,
+		Revision:            message.Revision,
+		RetractedAt:         message.RetractedAt,
+		CreatedAt:           message.CreatedAt,
+		UpdatedAt:           time.Now().UnixNano(),
+	}
+
+	inboxMessage, err = m.messageDataStore.Insert(ctx, inboxMessage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.blobManager.Reference(ctx, inboxMessage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = m.eventManager.Record(ctx, inbox, InboxEventTypeMessage, inboxMessage)
+
+	if err != nil {
+		zap.L().Error("error recording inbox event", zap.String("inbox", inbox.Identifier), zap.String("message", inboxMessage.Identifier), zap.Error(err))
+	}
+
+	err = m.webhookManager.Dispatch(ctx, inbox.ActorAddress, inbox.NodeIdentifier, inbox.Identifier, WebhookEventMessageReceived, inboxMessage)
+
+	if err != nil {
+		zap.L().Error("error dispatching webhooks", zap.String("inbox", inbox.Identifier), zap.String("message", inboxMessage.Identifier), zap.Error(err))
+	}
+
+	return inboxMessage, nil
+}
+
+func (m *InboxManager) Receive(ctx context.Context, request *DeliveryRequest, senderAddress string, targetNodeAddress string) (*Message, error) {
+	if request.SenderAddress != senderAddress {
+		return nil, reject(http.StatusForbidden, "sender %s does not match authenticated actor", request.SenderAddress)
+	}
+
+	recipient, err := ParseInboxAddress(request.RecipientAddress)
+
+	if err != nil {
+		return nil, reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	if recipient.Vertex != m.vertex || recipient.GetNodeAddress() != targetNodeAddress {
+		return nil, reject(http.StatusForbidden, "recipient %s does not match token audience", request.RecipientAddress)
+	}
+
+	message, err := m.open(ctx, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !message.IsAddressedTo(recipient.String()) {
+		return nil, reject(http.StatusForbidden, "message %s is not addressed to %s", message.Identifier, recipient.String())
+	}
+
+	return m.Deliver(ctx, message.addressedTo(recipient.String()))
+}
+
+func (m *InboxManager) ReceiveBatch(ctx context.Context, request *DeliveryBatchRequest, senderAddress string, audience string) (*DeliveryBatchResponse, error) {
+	if request.Message.SenderAddress != senderAddress {
+		return nil, reject(http.StatusForbidden, "sender %s does not match authenticated actor", request.Message.SenderAddress)
+	}
+
+	err := m.authorizeRecipients(request.RecipientAddresses, audience)
+
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := m.open(ctx, request.Message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DeliveryBatchResponse{}
+
+	for _, recipientAddress := range request.RecipientAddresses {
+		result := &DeliveryResult{RecipientAddress: recipientAddress, Status: DeliveryStatusDelivered}
+
+		err := m.receiveFor(ctx, message, recipientAddress)
+
+		if err != nil {
+			result.Status = DeliveryStatusRejected
+			result.StatusCode = rejectionStatusCode(err, http.StatusInternalServerError)
+			result.Error = err.Error()
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+func (m *InboxManager) authorizeRecipients(recipientAddresses []string, audience string) error {
+	for _, recipientAddress := range recipientAddresses {
+		recipient, err := ParseInboxAddress(recipientAddress)
+
+		if err != nil {
+			return reject(http.StatusBadRequest, "%s", err.Error())
+		}
+
+		if recipient.Vertex != m.vertex || (recipient.GetNodeAddress() != audience && recipient.Vertex != audience) {
+			return reject(http.StatusForbidden, "recipient %s does not match token audience", recipientAddress)
+		}
+	}
+
+	return nil
+}
+
+func (m *InboxManager) receiveFor(ctx context.Context, message *Message, recipientAddress string) error {
+	recipient, err := ParseInboxAddress(recipientAddress)
+
+	if err != nil {
+		return reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	if recipient.Vertex != m.vertex {
+		return reject(http.StatusForbidden, "recipient %s is not hosted on this vertex", recipientAddress)
+	}
+
+	if !message.IsAddressedTo(recipient.String()) {
+		return reject(http.StatusForbidden, "message %s is not addressed to %s", message.Identifier, recipient.String())
+	}
+
+	_, err = m.Deliver(ctx, message.addressedTo(recipient.String()))
+
+	if errors.Is(err, errMessageDropped) || errors.Is(err, errMessageDuplicate) {
+		return nil
+	}
+
 	return err
 }
+
+func (m *InboxManager) open(ctx context.Context, request *DeliveryRequest) (*Message, error) {
+	if !request.Retracted && (request.ContentType == "" || request.Content == "") {
+		return nil, reject(http.StatusBadRequest, "content_type and content are required")
+	}
+
+	err := validateEncryption(request.Encryption, request.EncryptionPublicKey, request.Content, len(request.RecipientAddresses))
+
+	if err != nil {
+		return nil, reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	for _, attachment := range request.Attachments {
+		if !isValidBlobHash(attachment.Hash) {
+			return nil, reject(http.StatusBadRequest, "invalid attachment hash %s", attachment.Hash)
+		}
+	}
+
+	message := &Message{
+		Identifier:          request.Identifier,
+		SenderAddress:       request.SenderAddress,
+		RecipientAddress:    request.RecipientAddress,
+		RecipientAddresses:  request.RecipientAddresses,
+		ContentType:         request.ContentType,
+		Content:             request.Content,
+		Encryption:          request.Encryption,
+		EncryptionPublicKey: request.EncryptionPublicKey,
+		ThreadIdentifier:    request.ThreadIdentifier,
+		InReplyTo:           request.InReplyTo,
+		Attachments:         request.Attachments,
+		Headers:             request.Headers,
+		Signature:           request.Signature,
+		Revision:            request.Revision,
+		CreatedAt:           request.CreatedAt,
+	}
+
+	if request.Retracted {
+		message.RetractedAt = time.Now().UnixNano()
+	}
+
+	err = m.envelopeManager.Verify(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (m *InboxManager) Subscribe(inbox *Inbox) *InboxSubscription {
+	return m.eventManager.Subscribe(inbox)
+}
+
+func (m *InboxManager) ListEvents(ctx context.Context, inbox *Inbox, sequence int64, size int64) ([]*InboxEvent, error) {
+	return m.eventManager.ListAfter(ctx, inbox, sequence, size)
+}
+
+func (m *InboxManager) ListMessages(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Message, error) {
+	inbox, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.messageDataStore.FindByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier, pagination)
+}
+
+func (m *InboxManager) GetMessage(ctx context.Context, messageIdentifier string, identifier string, actorAddress string, nodeIdentifier string) (*Message, error) {
+	inbox, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := m.messageDataStore.FindByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, messageIdentifier, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("message %s not found", messageIdentifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (m *InboxManager) VerifyMessage(ctx context.Context, messageIdentifier string, identifier string, actorAddress string, nodeIdentifier string) (*MessageVerificationResponse, error) {
+	message, err := m.GetMessage(ctx, messageIdentifier, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := &MessageVerificationResponse{
+		Identifier:    message.Identifier,
+		SenderAddress: message.SenderAddress,
+		Signature:     message.Signature,
+	}
+
+	err = m.envelopeManager.Verify(ctx, message)
+
+	var rejectionError *RejectionError
+
+	if errors.As(err, &rejectionError) {
+		response.Message = rejectionError.Message
+		return response, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	response.Verified = true
+	return response, nil
+}
+
+func (m *InboxManager) UpdateMessageState(ctx context.Context, messageIdentifier string, request *MessageStateUpdateRequest, identifier string, actorAddress string, nodeIdentifier string) error {
+	if !isValidMessageState(request.State) {
+		return fmt.Errorf("invalid message state %s", request.State)
+	}
+
+	inbox, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	return m.updateMessageState(ctx, inbox, messageIdentifier, request.State)
+}
+
+func (m *InboxManager) UpdateMessageStates(ctx context.Context, request *MessageStateBatchUpdateRequest, identifier string, actorAddress string, nodeIdentifier string) error {
+	if !isValidMessageState(request.State) {
+		return fmt.Errorf("invalid message state %s", request.State)
+	}
+
+	inbox, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	for _, messageIdentifier := range request.MessageIdentifiers {
+		err = m.updateMessageState(ctx, inbox, messageIdentifier, request.State)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *InboxManager) updateMessageState(ctx context.Context, inbox *Inbox, messageIdentifier string, state string) error {
+	message, err := m.messageDataStore.FindByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, messageIdentifier, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("message %s not found", messageIdentifier)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if message.State == state {
+		return nil
+	}
+
+	previousState := message.State
+	message.State = state
+	message.UpdatedAt = time.Now().UnixNano()
+
+	err = m.messageDataStore.UpdateStateByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message.State, message.Identifier, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier, message.UpdatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = m.eventManager.Record(ctx, inbox, InboxEventTypeState, message)
+
+	if err != nil {
+		zap.L().Error("error recording inbox event", zap.String("inbox", inbox.Identifier), zap.String("message", message.Identifier), zap.Error(err))
+	}
+
+	if previousState == MessageStateUnread && state == MessageStateRead {
+		err = m.webhookManager.Dispatch(ctx, inbox.ActorAddress, inbox.NodeIdentifier, inbox.Identifier, WebhookEventMessageRead, message)
+
+		if err != nil {
+			zap.L().Error("error dispatching webhooks", zap.String("inbox", inbox.Identifier), zap.String("message", message.Identifier), zap.Error(err))
+		}
+
+		if inbox.ReadReceipts {
+			err = m.receiptManager.Send(ctx, message, message.UpdatedAt)
+
+			if err != nil {
+				zap.L().Error("error sending read receipt", zap.String("inbox", inbox.Identifier), zap.String("message", message.Identifier), zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
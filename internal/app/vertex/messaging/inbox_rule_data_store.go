@@ -0,0 +1,183 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"go.uber.org/zap"
+)
+
+type InboxRuleDataStore struct {
+	db *sql.DB
+}
+
+func NewInboxRuleDataStore(db *sql.DB) *InboxRuleDataStore {
+	return &InboxRuleDataStore{db: db}
+}
+
+func (d *InboxRuleDataStore) Insert(ctx context.Context, rule *InboxRule) (*InboxRule, error) {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO inbox_rules (identifier, actor_address, node_identifier, inbox_identifier, position, field, header, pattern, action, target_inbox_identifier, label, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		rule.Identifier,
+		rule.ActorAddress,
+		rule.NodeIdentifier,
+		rule.InboxIdentifier,
+		rule.Position,
+		rule.Field,
+		rule.Header,
+		rule.Pattern,
+		rule.Action,
+		rule.TargetInboxIdentifier,
+		rule.Label,
+		rule.CreatedAt,
+		rule.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (d *InboxRuleDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Context, actorAddress string, nodeIdentifier string) ([]*InboxRule, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT identifier, actor_address, node_identifier, inbox_identifier, position, field, header, pattern, action, target_inbox_identifier, label, created_at, updated_at FROM inbox_rules WHERE actor_address = ? AND node_identifier = ? ORDER BY position, created_at",
+		actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var rules []*InboxRule
+
+	for rows.Next() {
+		var rule InboxRule
+		err = rows.Scan(
+			&rule.Identifier,
+			&rule.ActorAddress,
+			&rule.NodeIdentifier,
+			&rule.InboxIdentifier,
+			&rule.Position,
+			&rule.Field,
+			&rule.Header,
+			&rule.Pattern,
+			&rule.Action,
+			&rule.TargetInboxIdentifier,
+			&rule.Label,
+			&rule.CreatedAt,
+			&rule.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, &rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (d *InboxRuleDataStore) FindByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*InboxRule, error) {
+	var rule InboxRule
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT identifier, actor_address, node_identifier, inbox_identifier, position, field, header, pattern, action, target_inbox_identifier, label, created_at, updated_at FROM inbox_rules WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		identifier, actorAddress, nodeIdentifier).
+		Scan(
+			&rule.Identifier,
+			&rule.ActorAddress,
+			&rule.NodeIdentifier,
+			&rule.InboxIdentifier,
+			&rule.Position,
+			&rule.Field,
+			&rule.Header,
+			&rule.Pattern,
+			&rule.Action,
+			&rule.TargetInboxIdentifier,
+			&rule.Label,
+			&rule.CreatedAt,
+			&rule.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (d *InboxRuleDataStore) FindMaxPositionByActorAddressAndNodeIdentifier(ctx context.Context, actorAddress string, nodeIdentifier string) (int64, error) {
+	var position int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(position), -1) FROM inbox_rules WHERE actor_address = ? AND node_identifier = ?",
+		actorAddress, nodeIdentifier).Scan(&position)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return position, nil
+}
+
+func (d *InboxRuleDataStore) Update(ctx context.Context, rule *InboxRule) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE inbox_rules SET inbox_identifier = ?, position = ?, field = ?, header = ?, pattern = ?, action = ?, target_inbox_identifier = ?, label = ?, updated_at = ? WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		rule.InboxIdentifier,
+		rule.Position,
+		rule.Field,
+		rule.Header,
+		rule.Pattern,
+		rule.Action,
+		rule.TargetInboxIdentifier,
+		rule.Label,
+		rule.UpdatedAt,
+		rule.Identifier,
+		rule.ActorAddress,
+		rule.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *InboxRuleDataStore) DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
+	result, err := d.db.ExecContext(ctx,
+		"DELETE FROM inbox_rules WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type InboxRuleHandler struct {
+	router        *gin.Engine
+	authenticator *actor.Authenticator
+	manager       *InboxRuleManager
+}
+
+func NewInboxRuleHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *InboxRuleManager) *InboxRuleHandler {
+	return &InboxRuleHandler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *InboxRuleHandler) Register() {
+
+	h.router.POST("/api/v1/messaging/rules", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		var request InboxRuleRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		rule, err := h.manager.Create(ctx, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, rule)
+	})
+
+	h.router.GET("/api/v1/messaging/rules", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		rules, err := h.manager.List(ctx, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, rules)
+	})
+
+	h.router.GET("/api/v1/messaging/rules/:ruleIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("ruleIdentifier")
+
+		rule, err := h.manager.Get(ctx, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, rule)
+	})
+
+	h.router.PUT("/api/v1/messaging/rules/:ruleIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("ruleIdentifier")
+		var request InboxRuleRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.Update(ctx, identifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "rule updated successfully")
+	})
+
+	h.router.DELETE("/api/v1/messaging/rules/:ruleIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("ruleIdentifier")
+
+		err = h.manager.Delete(ctx, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "rule deleted successfully")
+	})
+}
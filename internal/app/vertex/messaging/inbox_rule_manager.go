@@ -0,0 +1,310 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/evernetproto/evernet/internal/pkg/ids"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const inboxRulePatternCacheSize = 1024
+
+type InboxRuleManager struct {
+	dataStore      *InboxRuleDataStore
+	inboxDataStore *InboxDataStore
+	patterns       map[string]*regexp.Regexp
+	mutex          sync.Mutex
+}
+
+func NewInboxRuleManager(dataStore *InboxRuleDataStore, inboxDataStore *InboxDataStore) *InboxRuleManager {
+	return &InboxRuleManager{dataStore: dataStore, inboxDataStore: inboxDataStore, patterns: make(map[string]*regexp.Regexp)}
+}
+
+type inboxRuleOutcome struct {
+	drop                  bool
+	targetInboxIdentifier string
+	labels                []string
+}
+
+func (o *inboxRuleOutcome) addLabel(label string) {
+	for _, existing := range o.labels {
+		if existing == label {
+			return
+		}
+	}
+
+	o.labels = append(o.labels, label)
+}
+
+func (m *InboxRuleManager) Create(ctx context.Context, request *InboxRuleRequest, actorAddress string, nodeIdentifier string) (*InboxRule, error) {
+	err := m.validate(ctx, request, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	identifier, err := ids.Generate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var position int64
+
+	if request.Position != nil {
+		position = *request.Position
+	} else {
+		maxPosition, err := m.dataStore.FindMaxPositionByActorAddressAndNodeIdentifier(ctx, actorAddress, nodeIdentifier)
+
+		if err != nil {
+			return nil, err
+		}
+
+		position = maxPosition + 1
+	}
+
+	rule := &InboxRule{
+		Identifier:            identifier,
+		ActorAddress:          actorAddress,
+		NodeIdentifier:        nodeIdentifier,
+		InboxIdentifier:       request.InboxIdentifier,
+		Position:              position,
+		Field:                 request.Field,
+		Header:                request.Header,
+		Pattern:               request.Pattern,
+		Action:                request.Action,
+		TargetInboxIdentifier: request.TargetInboxIdentifier,
+		Label:                 request.Label,
+		CreatedAt:             time.Now().UnixNano(),
+		UpdatedAt:             time.Now().UnixNano(),
+	}
+
+	return m.dataStore.Insert(ctx, rule)
+}
+
+func (m *InboxRuleManager) List(ctx context.Context, actorAddress string, nodeIdentifier string) ([]*InboxRule, error) {
+	return m.dataStore.FindByActorAddressAndNodeIdentifier(ctx, actorAddress, nodeIdentifier)
+}
+
+func (m *InboxRuleManager) Get(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*InboxRule, error) {
+	rule, err := m.dataStore.FindByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reject(http.StatusNotFound, "rule %s not found", identifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (m *InboxRuleManager) Update(ctx context.Context, identifier string, request *InboxRuleRequest, actorAddress string, nodeIdentifier string) error {
+	rule, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.validate(ctx, request, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	if request.Position != nil {
+		rule.Position = *request.Position
+	}
+
+	rule.InboxIdentifier = request.InboxIdentifier
+	rule.Field = request.Field
+	rule.Header = request.Header
+	rule.Pattern = request.Pattern
+	rule.Action = request.Action
+	rule.TargetInboxIdentifier = request.TargetInboxIdentifier
+	rule.Label = request.Label
+	rule.UpdatedAt = time.Now().UnixNano()
+
+	err = m.dataStore.Update(ctx, rule)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "rule %s not found", identifier)
+	}
+
+	return err
+}
+
+func (m *InboxRuleManager) Delete(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
+	err := m.dataStore.DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "rule %s not found", identifier)
+	}
+
+	return err
+}
+
+func (m *InboxRuleManager) Evaluate(ctx context.Context, inbox *Inbox, message *Message) (*inboxRuleOutcome, error) {
+	rules, err := m.dataStore.FindByActorAddressAndNodeIdentifier(ctx, inbox.ActorAddress, inbox.NodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := &inboxRuleOutcome{}
+
+	for _, rule := range rules {
+		if rule.InboxIdentifier != "" && rule.InboxIdentifier != inbox.Identifier {
+			continue
+		}
+
+		pattern, err := m.compile(rule.Pattern)
+
+		if err != nil {
+			continue
+		}
+
+		if !rule.matches(message, pattern) {
+			continue
+		}
+
+		switch rule.Action {
+		case InboxRuleActionLabel:
+			outcome.addLabel(rule.Label)
+		case InboxRuleActionMove:
+			outcome.targetInboxIdentifier = rule.TargetInboxIdentifier
+			return outcome, nil
+		case InboxRuleActionDrop:
+			outcome.drop = true
+			return outcome, nil
+		}
+	}
+
+	return outcome, nil
+}
+
+func (m *InboxRuleManager) validate(ctx context.Context, request *InboxRuleRequest, actorAddress string, nodeIdentifier string) error {
+	switch request.Field {
+	case InboxRuleFieldSenderAddress, InboxRuleFieldSenderVertex, InboxRuleFieldContentType:
+	case InboxRuleFieldHeader:
+		if request.Header == "" {
+			return reject(http.StatusBadRequest, "header is required for header rules")
+		}
+	default:
+		return reject(http.StatusBadRequest, "invalid rule field %s", request.Field)
+	}
+
+	_, err := compilePattern(request.Pattern)
+
+	if err != nil {
+		return reject(http.StatusBadRequest, "invalid rule pattern %s", request.Pattern)
+	}
+
+	if request.InboxIdentifier != "" {
+		err = m.ensureInbox(ctx, request.InboxIdentifier, actorAddress, nodeIdentifier)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	switch request.Action {
+	case InboxRuleActionMove:
+		if request.TargetInboxIdentifier == "" {
+			return reject(http.StatusBadRequest, "target inbox is required for move rules")
+		}
+
+		return m.ensureInbox(ctx, request.TargetInboxIdentifier, actorAddress, nodeIdentifier)
+	case InboxRuleActionLabel:
+		if request.Label == "" {
+			return reject(http.StatusBadRequest, "label is required for label rules")
+		}
+	case InboxRuleActionDrop:
+	default:
+		return reject(http.StatusBadRequest, "invalid rule action %s", request.Action)
+	}
+
+	return nil
+}
+
+func (m *InboxRuleManager) ensureInbox(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
+	_, err := m.inboxDataStore.FindByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusBadRequest, "inbox %s not found", identifier)
+	}
+
+	return err
+}
+
+func (m *InboxRuleManager) compile(pattern string) (*regexp.Regexp, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	compiled, ok := m.patterns[pattern]
+
+	if ok {
+		return compiled, nil
+	}
+
+	compiled, err := compilePattern(pattern)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.patterns) >= inboxRulePatternCacheSize {
+		clear(m.patterns)
+	}
+
+	m.patterns[pattern] = compiled
+
+	return compiled, nil
+}
+
+func (r *InboxRule) matches(message *Message, pattern *regexp.Regexp) bool {
+	var value string
+
+	switch r.Field {
+	case InboxRuleFieldSenderAddress:
+		value = message.SenderAddress
+	case InboxRuleFieldSenderVertex:
+		sender, err := ParseActorAddress(message.SenderAddress)
+
+		if err != nil {
+			return false
+		}
+
+		value = sender.Vertex
+	case InboxRuleFieldContentType:
+		value = message.ContentType
+	case InboxRuleFieldHeader:
+		header, ok := message.GetHeader(r.Header)
+
+		if !ok {
+			return false
+		}
+
+		value = header
+	default:
+		return false
+	}
+
+	return pattern.MatchString(value)
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	components := strings.Split(pattern, "*")
+
+	for i, component := range components {
+		components[i] = regexp.QuoteMeta(component)
+	}
+
+	return regexp.Compile("(?i)^" + strings.Join(components, ".*") + "$")
+}
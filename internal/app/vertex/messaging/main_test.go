@@ -0,0 +1,31 @@
+package messaging
+
+import (
+	"database/sql"
+	"github.com/evernetproto/evernet/internal/app/vertex/db"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	err := os.Chdir("../../../..")
+
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newTestDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	database := db.MigrateDatabase(filepath.Join(t.TempDir(), "vertex.db"), "vertex")
+
+	t.Cleanup(func() {
+		_ = database.Close()
+	})
+
+	return database
+}
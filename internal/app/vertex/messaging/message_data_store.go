@@ -0,0 +1,451 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"go.uber.org/zap"
+	"strings"
+)
+
+type MessageDataStore struct {
+	db *sql.DB
+}
+
+func NewMessageDataStore(db *sql.DB) *MessageDataStore {
+	return &MessageDataStore{db: db}
+}
+
+func (d *MessageDataStore) Insert(ctx context.Context, message *Message) (*Message, error) {
+	attachments, err := encodeJSON(message.Attachments, len(message.Attachments))
+
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := encodeJSON(message.Headers, len(message.Headers))
+
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := encodeJSON(message.Labels, len(message.Labels))
+
+	if err != nil {
+		return nil, err
+	}
+
+	recipientAddresses, err := encodeJSON(message.RecipientAddresses, len(message.RecipientAddresses))
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = d.db.ExecContext(ctx,
+		"INSERT INTO messages (identifier, box_type, box_identifier, node_identifier, actor_address, sender_address, recipient_address, recipient_addresses, content_type, content, encryption, encryption_public_key, signature, state, thread_identifier, in_reply_to, attachments, headers, labels, revision, retracted_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		message.Identifier,
+		message.BoxType,
+		message.BoxIdentifier,
+		message.NodeIdentifier,
+		message.ActorAddress,
+		message.SenderAddress,
+		message.RecipientAddress,
+		recipientAddresses,
+		message.ContentType,
+		message.Content,
+		message.Encryption,
+		message.EncryptionPublicKey,
+		message.Signature,
+		message.State,
+		message.ThreadIdentifier,
+		message.InReplyTo,
+		attachments,
+		headers,
+		labels,
+		message.Revision,
+		message.RetractedAt,
+		message.CreatedAt,
+		message.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (d *MessageDataStore) FindByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string, pagination *api.Pagination) ([]*Message, error) {
+	createdAt, identifier := pagination.Bounds(true)
+	return d.findAll(ctx,
+		"SELECT identifier, box_type, box_identifier, node_identifier, actor_address, sender_address, recipient_address, recipient_addresses, content_type, content, encryption, encryption_public_key, signature, state, thread_identifier, in_reply_to, attachments, headers, labels, revision, retracted_at, created_at, updated_at FROM messages WHERE box_type = ? AND box_identifier = ? AND node_identifier = ? AND (created_at, identifier) < (?, ?) ORDER BY created_at DESC, identifier DESC LIMIT ? OFFSET ?",
+		boxType, boxIdentifier, nodeIdentifier, createdAt, identifier, pagination.Size, pagination.Offset())
+}
+
+func (d *MessageDataStore) FindByThreadIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, threadIdentifier string, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Message, error) {
+	createdAt, identifier := pagination.Bounds(false)
+
+	return d.findAll(ctx,
+		"SELECT m.identifier, m.box_type, m.box_identifier, m.node_identifier, m.actor_address, m.sender_address, m.recipient_address, m.recipient_addresses, m.content_type, m.content, m.encryption, m.encryption_public_key, m.signature, m.state, m.thread_identifier, m.in_reply_to, m.attachments, m.headers, m.labels, m.revision, m.retracted_at, m.created_at, m.updated_at FROM messages m WHERE m.thread_identifier = ? AND m.actor_address = ? AND m.node_identifier = ? AND (m.created_at, m.identifier) > (?, ?) AND NOT EXISTS (SELECT 1 FROM messages o WHERE o.thread_identifier = m.thread_identifier AND o.actor_address = m.actor_address AND o.node_identifier = m.node_identifier AND o.identifier = m.identifier AND o.box_type < m.box_type) ORDER BY m.created_at, m.identifier LIMIT ? OFFSET ?",
+		threadIdentifier, actorAddress, nodeIdentifier, createdAt, identifier, pagination.Size, pagination.Offset())
+}
+
+func (d *MessageDataStore) FindByBoxTypeAndBoxIdentifierAndNodeIdentifierAndCreatedAtBefore(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string, createdAt int64, offset int64, size int64) ([]*Message, error) {
+	return d.findAll(ctx,
+		"SELECT identifier, box_type, box_identifier, node_identifier, actor_address, sender_address, recipient_address, recipient_addresses, content_type, content, encryption, encryption_public_key, signature, state, thread_identifier, in_reply_to, attachments, headers, labels, revision, retracted_at, created_at, updated_at FROM messages WHERE box_type = ? AND box_identifier = ? AND node_identifier = ? AND created_at < ? ORDER BY created_at, identifier LIMIT ? OFFSET ?",
+		boxType, boxIdentifier, nodeIdentifier, createdAt, size, offset)
+}
+
+func (d *MessageDataStore) FindByBoxTypeAndBoxIdentifierAndNodeIdentifierWithOffset(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string, offset int64, size int64) ([]*Message, error) {
+	return d.findAll(ctx,
+		"SELECT identifier, box_type, box_identifier, node_identifier, actor_address, sender_address, recipient_address, recipient_addresses, content_type, content, encryption, encryption_public_key, signature, state, thread_identifier, in_reply_to, attachments, headers, labels, revision, retracted_at, created_at, updated_at FROM messages WHERE box_type = ? AND box_identifier = ? AND node_identifier = ? ORDER BY created_at DESC, identifier DESC LIMIT ? OFFSET ?",
+		boxType, boxIdentifier, nodeIdentifier, size, offset)
+}
+
+func (d *MessageDataStore) Search(ctx context.Context, request *MessageSearchRequest, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Message, error) {
+	query := "SELECT m.identifier, m.box_type, m.box_identifier, m.node_identifier, m.actor_address, m.sender_address, m.recipient_address, m.recipient_addresses, m.content_type, m.content, m.encryption, m.encryption_public_key, m.signature, m.state, m.thread_identifier, m.in_reply_to, m.attachments, m.headers, m.labels, m.revision, m.retracted_at, m.created_at, m.updated_at FROM message_search s JOIN message_search_keys k ON k.id = s.rowid JOIN messages m ON m.identifier = k.identifier AND m.box_type = k.box_type AND m.box_identifier = k.box_identifier AND m.node_identifier = k.node_identifier WHERE message_search MATCH ? AND m.actor_address = ? AND m.node_identifier = ? AND NOT EXISTS (SELECT 1 FROM messages o WHERE o.actor_address = m.actor_address AND o.node_identifier = m.node_identifier AND o.identifier = m.identifier AND o.box_type < m.box_type)"
+	args := []interface{}{searchExpression(request.Query), actorAddress, nodeIdentifier}
+
+	if request.InboxIdentifier != "" {
+		query += " AND m.box_type = ? AND m.box_identifier = ?"
+		args = append(args, BoxTypeInbox, request.InboxIdentifier)
+	}
+
+	if request.SenderAddress != "" {
+		query += " AND m.sender_address = ?"
+		args = append(args, request.SenderAddress)
+	}
+
+	if request.From > 0 {
+		query += " AND m.created_at >= ?"
+		args = append(args, request.From)
+	}
+
+	if request.To > 0 {
+		query += " AND m.created_at <= ?"
+		args = append(args, request.To)
+	}
+
+	if request.Label != "" {
+		query += " AND EXISTS (SELECT 1 FROM json_each(NULLIF(m.labels, '')) WHERE value = ?)"
+		args = append(args, request.Label)
+	}
+
+	createdAt, identifier := pagination.Bounds(true)
+
+	query += " AND (m.created_at, m.identifier) < (?, ?) ORDER BY m.created_at DESC, m.identifier DESC LIMIT ? OFFSET ?"
+	args = append(args, createdAt, identifier, pagination.Size, pagination.Offset())
+
+	return d.findAll(ctx, query, args...)
+}
+
+func (d *MessageDataStore) findAll(ctx context.Context, query string, args ...interface{}) ([]*Message, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var messages []*Message
+
+	for rows.Next() {
+		var message Message
+		var recipientAddresses, attachments, headers, labels string
+		err = rows.Scan(
+			&message.Identifier,
+			&message.BoxType,
+			&message.BoxIdentifier,
+			&message.NodeIdentifier,
+			&message.ActorAddress,
+			&message.SenderAddress,
+			&message.RecipientAddress,
+			&recipientAddresses,
+			&message.ContentType,
+			&message.Content,
+			&message.Encryption,
+			&message.EncryptionPublicKey,
+			&message.Signature,
+			&message.State,
+			&message.ThreadIdentifier,
+			&message.InReplyTo,
+			&attachments,
+			&headers,
+			&labels,
+			&message.Revision,
+			&message.RetractedAt,
+			&message.CreatedAt,
+			&message.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeJSON(attachments, &message.Attachments)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeJSON(headers, &message.Headers)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeJSON(labels, &message.Labels)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeJSON(recipientAddresses, &message.RecipientAddresses)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, &message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (d *MessageDataStore) FindByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, identifier string, boxType string, boxIdentifier string, nodeIdentifier string) (*Message, error) {
+	var message Message
+	var recipientAddresses, attachments, headers, labels string
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT identifier, box_type, box_identifier, node_identifier, actor_address, sender_address, recipient_address, recipient_addresses, content_type, content, encryption, encryption_public_key, signature, state, thread_identifier, in_reply_to, attachments, headers, labels, revision, retracted_at, created_at, updated_at FROM messages WHERE identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		identifier, boxType, boxIdentifier, nodeIdentifier).
+		Scan(
+			&message.Identifier,
+			&message.BoxType,
+			&message.BoxIdentifier,
+			&message.NodeIdentifier,
+			&message.ActorAddress,
+			&message.SenderAddress,
+			&message.RecipientAddress,
+			&recipientAddresses,
+			&message.ContentType,
+			&message.Content,
+			&message.Encryption,
+			&message.EncryptionPublicKey,
+			&message.Signature,
+			&message.State,
+			&message.ThreadIdentifier,
+			&message.InReplyTo,
+			&attachments,
+			&headers,
+			&labels,
+			&message.Revision,
+			&message.RetractedAt,
+			&message.CreatedAt,
+			&message.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = decodeJSON(attachments, &message.Attachments)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = decodeJSON(headers, &message.Headers)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = decodeJSON(labels, &message.Labels)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = decodeJSON(recipientAddresses, &message.RecipientAddresses)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (d *MessageDataStore) FindFirstByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*Message, error) {
+	messages, err := d.findAll(ctx,
+		"SELECT identifier, box_type, box_identifier, node_identifier, actor_address, sender_address, recipient_address, recipient_addresses, content_type, content, encryption, encryption_public_key, signature, state, thread_identifier, in_reply_to, attachments, headers, labels, revision, retracted_at, created_at, updated_at FROM messages WHERE identifier = ? AND actor_address = ? AND node_identifier = ? ORDER BY box_type LIMIT 1",
+		identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(messages) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return messages[0], nil
+}
+
+func (d *MessageDataStore) DeleteByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM messages WHERE box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		boxType, boxIdentifier, nodeIdentifier)
+
+	return err
+}
+
+func (d *MessageDataStore) DeleteByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, identifier string, boxType string, boxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM messages WHERE identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		identifier, boxType, boxIdentifier, nodeIdentifier)
+
+	return err
+}
+
+func (d *MessageDataStore) ExistsOutboxByActorAddressAndNodeIdentifierAndRecipientActorAddress(ctx context.Context, actorAddress string, nodeIdentifier string, recipientActorAddress string) (bool, error) {
+	var count int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM messages WHERE box_type = ? AND actor_address = ? AND node_identifier = ? AND (substr(recipient_address, 1, ?) = ? OR EXISTS (SELECT 1 FROM json_each(NULLIF(recipient_addresses, '')) WHERE substr(value, 1, ?) = ?))",
+		BoxTypeOutbox, actorAddress, nodeIdentifier, len(recipientActorAddress)+1, recipientActorAddress+"/", len(recipientActorAddress)+1, recipientActorAddress+"/").Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *MessageDataStore) FindByIdentifierAndBoxTypeAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, boxType string, actorAddress string, nodeIdentifier string) ([]*Message, error) {
+	return d.findAll(ctx,
+		"SELECT identifier, box_type, box_identifier, node_identifier, actor_address, sender_address, recipient_address, recipient_addresses, content_type, content, encryption, encryption_public_key, signature, state, thread_identifier, in_reply_to, attachments, headers, labels, revision, retracted_at, created_at, updated_at FROM messages WHERE identifier = ? AND box_type = ? AND actor_address = ? AND node_identifier = ?",
+		identifier, boxType, actorAddress, nodeIdentifier)
+}
+
+func (d *MessageDataStore) UpdateRevisionByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, message *Message) error {
+	attachments, err := encodeJSON(message.Attachments, len(message.Attachments))
+
+	if err != nil {
+		return err
+	}
+
+	headers, err := encodeJSON(message.Headers, len(message.Headers))
+
+	if err != nil {
+		return err
+	}
+
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE messages SET content_type = ?, content = ?, encryption = ?, encryption_public_key = ?, attachments = ?, headers = ?, signature = ?, revision = ?, retracted_at = ?, updated_at = ? WHERE identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ? AND revision < ?",
+		message.ContentType,
+		message.Content,
+		message.Encryption,
+		message.EncryptionPublicKey,
+		attachments,
+		headers,
+		message.Signature,
+		message.Revision,
+		message.RetractedAt,
+		message.UpdatedAt,
+		message.Identifier,
+		message.BoxType,
+		message.BoxIdentifier,
+		message.NodeIdentifier,
+		message.Revision)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *MessageDataStore) UpdateStateByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, state string, identifier string, boxType string, boxIdentifier string, nodeIdentifier string, updatedAt int64) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE messages SET state = ?, updated_at = ? WHERE identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		state, updatedAt, identifier, boxType, boxIdentifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *MessageDataStore) CountByBoxTypeAndBoxIdentifierAndNodeIdentifierAndState(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string, state string) (int64, error) {
+	var count int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM messages WHERE box_type = ? AND box_identifier = ? AND node_identifier = ? AND state = ?",
+		boxType, boxIdentifier, nodeIdentifier, state).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func searchExpression(query string) string {
+	terms := strings.Fields(query)
+
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func encodeJSON(value interface{}, length int) (string, error) {
+	if length == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func decodeJSON(data string, value interface{}) error {
+	if data == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(data), value)
+}
@@ -0,0 +1,121 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"go.uber.org/zap"
+)
+
+type MessageRevisionDataStore struct {
+	db *sql.DB
+}
+
+func NewMessageRevisionDataStore(db *sql.DB) *MessageRevisionDataStore {
+	return &MessageRevisionDataStore{db: db}
+}
+
+func (d *MessageRevisionDataStore) Insert(ctx context.Context, revision *MessageRevision) (*MessageRevision, error) {
+	attachments, err := encodeJSON(revision.Attachments, len(revision.Attachments))
+
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := encodeJSON(revision.Headers, len(revision.Headers))
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = d.db.ExecContext(ctx,
+		"INSERT INTO message_revisions (message_identifier, box_type, box_identifier, node_identifier, revision, content_type, content, encryption, encryption_public_key, attachments, headers, signature, replaced_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (message_identifier, box_type, box_identifier, node_identifier, revision) DO NOTHING",
+		revision.MessageIdentifier,
+		revision.BoxType,
+		revision.BoxIdentifier,
+		revision.NodeIdentifier,
+		revision.Revision,
+		revision.ContentType,
+		revision.Content,
+		revision.Encryption,
+		revision.EncryptionPublicKey,
+		attachments,
+		headers,
+		revision.Signature,
+		revision.ReplacedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+func (d *MessageRevisionDataStore) FindByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, messageIdentifier string, boxType string, boxIdentifier string, nodeIdentifier string) ([]*MessageRevision, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT message_identifier, box_type, box_identifier, node_identifier, revision, content_type, content, encryption, encryption_public_key, attachments, headers, signature, replaced_at FROM message_revisions WHERE message_identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ? ORDER BY revision",
+		messageIdentifier, boxType, boxIdentifier, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var revisions []*MessageRevision
+
+	for rows.Next() {
+		var revision MessageRevision
+		var attachments, headers string
+		err = rows.Scan(
+			&revision.MessageIdentifier,
+			&revision.BoxType,
+			&revision.BoxIdentifier,
+			&revision.NodeIdentifier,
+			&revision.Revision,
+			&revision.ContentType,
+			&revision.Content,
+			&revision.Encryption,
+			&revision.EncryptionPublicKey,
+			&attachments,
+			&headers,
+			&revision.Signature,
+			&revision.ReplacedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeJSON(attachments, &revision.Attachments)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeJSON(headers, &revision.Headers)
+
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, &revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (d *MessageRevisionDataStore) DeleteByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx context.Context, messageIdentifier string, boxType string, boxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM message_revisions WHERE message_identifier = ? AND box_type = ? AND box_identifier = ? AND node_identifier = ?",
+		messageIdentifier, boxType, boxIdentifier, nodeIdentifier)
+
+	return err
+}
@@ -1,19 +1,486 @@
 package messaging
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
 type Inbox struct {
-	Identifier     string `json:"identifier" db:"identifier"`
-	DisplayName    string `json:"display_name" db:"display_name"`
-	NodeIdentifier string `json:"node_identifier" db:"node_identifier"`
-	ActorAddress   string `json:"actor_address" db:"actor_address"`
-	CreatedAt      int64  `json:"created_at" db:"created_at"`
-	UpdatedAt      int64  `json:"updated_at" db:"updated_at"`
+	Identifier        string `json:"identifier" db:"identifier"`
+	DisplayName       string `json:"display_name" db:"display_name"`
+	NodeIdentifier    string `json:"node_identifier" db:"node_identifier"`
+	ActorAddress      string `json:"actor_address" db:"actor_address"`
+	ReadReceipts      bool   `json:"read_receipts" db:"read_receipts"`
+	Policy            string `json:"policy" db:"policy"`
+	RetentionMaxAge   int64  `json:"retention_max_age" db:"retention_max_age"`
+	RetentionMaxCount int64  `json:"retention_max_count" db:"retention_max_count"`
+	CreatedAt         int64  `json:"created_at" db:"created_at"`
+	UpdatedAt         int64  `json:"updated_at" db:"updated_at"`
+}
+
+const (
+	InboxPolicyOpen      = "open"
+	InboxPolicyContacts  = "contacts"
+	InboxPolicyAllowlist = "allowlist"
+)
+
+func isValidInboxPolicy(policy string) bool {
+	return policy == InboxPolicyOpen || policy == InboxPolicyContacts || policy == InboxPolicyAllowlist
+}
+
+const (
+	InboxAccessListAllow = "allow"
+	InboxAccessListBlock = "block"
+)
+
+type InboxAccessEntry struct {
+	InboxIdentifier string `json:"inbox_identifier" db:"inbox_identifier"`
+	NodeIdentifier  string `json:"node_identifier" db:"node_identifier"`
+	ActorAddress    string `json:"actor_address" db:"actor_address"`
+	List            string `json:"list" db:"list"`
+	Entry           string `json:"entry" db:"entry"`
+	CreatedAt       int64  `json:"created_at" db:"created_at"`
 }
 
 type Outbox struct {
-	Identifier     string `json:"identifier" db:"identifier"`
-	DisplayName    string `json:"display_name" db:"display_name"`
-	NodeIdentifier string `json:"node_identifier" db:"node_identifier"`
-	ActorAddress   string `json:"actor_address" db:"actor_address"`
-	CreatedAt      int64  `json:"created_at" db:"created_at"`
-	UpdatedAt      int64  `json:"updated_at" db:"updated_at"`
+	Identifier        string `json:"identifier" db:"identifier"`
+	DisplayName       string `json:"display_name" db:"display_name"`
+	NodeIdentifier    string `json:"node_identifier" db:"node_identifier"`
+	ActorAddress      string `json:"actor_address" db:"actor_address"`
+	RetentionMaxAge   int64  `json:"retention_max_age" db:"retention_max_age"`
+	RetentionMaxCount int64  `json:"retention_max_count" db:"retention_max_count"`
+	CreatedAt         int64  `json:"created_at" db:"created_at"`
+	UpdatedAt         int64  `json:"updated_at" db:"updated_at"`
+}
+
+const (
+	BoxTypeInbox  = "inbox"
+	BoxTypeOutbox = "outbox"
+)
+
+type Message struct {
+	Identifier          string            `json:"identifier" db:"identifier"`
+	BoxType             string            `json:"box_type" db:"box_type"`
+	BoxIdentifier       string            `json:"box_identifier" db:"box_identifier"`
+	NodeIdentifier      string            `json:"node_identifier" db:"node_identifier"`
+	ActorAddress        string            `json:"actor_address" db:"actor_address"`
+	SenderAddress       string            `json:"sender_address" db:"sender_address"`
+	RecipientAddress    string            `json:"recipient_address" db:"recipient_address"`
+	RecipientAddresses  []string          `json:"recipient_addresses,omitempty" db:"recipient_addresses"`
+	ContentType         string            `json:"content_type" db:"content_type"`
+	Content             string            `json:"content" db:"content"`
+	Encryption          string            `json:"encryption" db:"encryption"`
+	EncryptionPublicKey string            `json:"encryption_public_key" db:"encryption_public_key"`
+	Signature           string            `json:"signature" db:"signature"`
+	State               string            `json:"state" db:"state"`
+	ThreadIdentifier    string            `json:"thread_identifier" db:"thread_identifier"`
+	InReplyTo           string            `json:"in_reply_to" db:"in_reply_to"`
+	Attachments         []*Attachment     `json:"attachments" db:"attachments"`
+	Headers             map[string]string `json:"headers" db:"headers"`
+	Labels              []string          `json:"labels" db:"labels"`
+	Revision            int64             `json:"revision" db:"revision"`
+	RetractedAt         int64             `json:"retracted_at" db:"retracted_at"`
+	CreatedAt           int64             `json:"created_at" db:"created_at"`
+	UpdatedAt           int64             `json:"updated_at" db:"updated_at"`
+}
+
+func (m *Message) GetEnvelope() *Envelope {
+	recipientAddress := m.RecipientAddress
+
+	if len(m.RecipientAddresses) > 0 {
+		recipientAddress = ""
+	}
+
+	return &Envelope{
+		Identifier:          m.Identifier,
+		SenderAddress:       m.SenderAddress,
+		RecipientAddress:    recipientAddress,
+		RecipientAddresses:  m.RecipientAddresses,
+		ContentType:         m.ContentType,
+		Content:             m.Content,
+		Encryption:          m.Encryption,
+		EncryptionPublicKey: m.EncryptionPublicKey,
+		ThreadIdentifier:    m.ThreadIdentifier,
+		InReplyTo:           m.InReplyTo,
+		Attachments:         m.Attachments,
+		Headers:             m.Headers,
+		Revision:            m.Revision,
+		Retracted:           m.RetractedAt > 0,
+		CreatedAt:           m.CreatedAt,
+	}
+}
+
+type Envelope struct {
+	Identifier          string            `json:"identifier"`
+	SenderAddress       string            `json:"sender_address"`
+	RecipientAddress    string            `json:"recipient_address"`
+	RecipientAddresses  []string          `json:"recipient_addresses,omitempty"`
+	ContentType         string            `json:"content_type"`
+	Content             string            `json:"content"`
+	Encryption          string            `json:"encryption,omitempty"`
+	EncryptionPublicKey string            `json:"encryption_public_key,omitempty"`
+	ThreadIdentifier    string            `json:"thread_identifier,omitempty"`
+	InReplyTo           string            `json:"in_reply_to,omitempty"`
+	Attachments         []*Attachment     `json:"attachments,omitempty"`
+	Headers             map[string]string `json:"headers,omitempty"`
+	Revision            int64             `json:"revision,omitempty"`
+	Retracted           bool              `json:"retracted,omitempty"`
+	CreatedAt           int64             `json:"created_at"`
+}
+
+func (m *Message) IsAddressedTo(recipientAddress string) bool {
+	if len(m.RecipientAddresses) == 0 {
+		return m.RecipientAddress == recipientAddress
+	}
+
+	return slices.Contains(m.RecipientAddresses, recipientAddress)
+}
+
+func (m *Message) addressedTo(recipientAddress string) *Message {
+	message := *m
+	message.RecipientAddress = recipientAddress
+	return &message
+}
+
+func (m *Message) isDuplicateOf(message *Message) bool {
+	if m.SenderAddress != message.SenderAddress || m.CreatedAt != message.CreatedAt {
+		return false
+	}
+
+	if m.Revision > message.Revision {
+		return true
+	}
+
+	return m.Revision == message.Revision &&
+		m.ContentType == message.ContentType &&
+		m.Content == message.Content &&
+		m.Encryption == message.Encryption &&
+		m.EncryptionPublicKey == message.EncryptionPublicKey &&
+		m.Signature == message.Signature
+}
+
+func (e *Envelope) Canonicalize() ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(e); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+const (
+	MessageStateUnread   = "unread"
+	MessageStateRead     = "read"
+	MessageStateArchived = "archived"
+)
+
+func isValidMessageState(state string) bool {
+	return state == MessageStateUnread || state == MessageStateRead || state == MessageStateArchived
+}
+
+func (m *Message) GetHeader(name string) (string, bool) {
+	for key, value := range m.Headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+type Attachment struct {
+	Hash        string `json:"hash" binding:"required"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+type Blob struct {
+	Hash        string `json:"hash" db:"hash"`
+	Size        int64  `json:"size" db:"size"`
+	ContentType string `json:"content_type" db:"content_type"`
+	Stored      bool   `json:"-" db:"stored"`
+	CreatedAt   int64  `json:"created_at" db:"created_at"`
+	UpdatedAt   int64  `json:"updated_at" db:"updated_at"`
+}
+
+type InboxAddress struct {
+	Vertex          string
+	NodeIdentifier  string
+	ActorIdentifier string
+	InboxIdentifier string
+}
+
+func ParseInboxAddress(address string) (*InboxAddress, error) {
+	components := strings.Split(address, "/")
+
+	if len(components) != 4 {
+		return nil, fmt.Errorf("invalid inbox address %s", address)
+	}
+
+	for _, component := range components {
+		if component == "" {
+			return nil, fmt.Errorf("invalid inbox address %s", address)
+		}
+	}
+
+	return &InboxAddress{
+		Vertex:          components[0],
+		NodeIdentifier:  components[1],
+		ActorIdentifier: components[2],
+		InboxIdentifier: components[3],
+	}, nil
+}
+
+func (a *InboxAddress) GetNodeAddress() string {
+	return fmt.Sprintf("%s/%s", a.Vertex, a.NodeIdentifier)
+}
+
+func (a *InboxAddress) GetActorAddress() string {
+	return fmt.Sprintf("%s/%s/%s", a.Vertex, a.NodeIdentifier, a.ActorIdentifier)
+}
+
+func (a *InboxAddress) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s", a.Vertex, a.NodeIdentifier, a.ActorIdentifier, a.InboxIdentifier)
+}
+
+type ActorAddress struct {
+	Vertex         string
+	NodeIdentifier string
+	Identifier     string
+}
+
+func ParseActorAddress(address string) (*ActorAddress, error) {
+	components := strings.Split(address, "/")
+
+	if len(components) != 3 {
+		return nil, fmt.Errorf("invalid actor address %s", address)
+	}
+
+	for _, component := range components {
+		if component == "" {
+			return nil, fmt.Errorf("invalid actor address %s", address)
+		}
+	}
+
+	return &ActorAddress{
+		Vertex:         components[0],
+		NodeIdentifier: components[1],
+		Identifier:     components[2],
+	}, nil
+}
+
+func (a *ActorAddress) GetNodeAddress() string {
+	return fmt.Sprintf("%s/%s", a.Vertex, a.NodeIdentifier)
+}
+
+func (a *ActorAddress) String() string {
+	return fmt.Sprintf("%s/%s/%s", a.Vertex, a.NodeIdentifier, a.Identifier)
+}
+
+const (
+	InboxRuleFieldSenderAddress = "sender_address"
+	InboxRuleFieldSenderVertex  = "sender_vertex"
+	InboxRuleFieldContentType   = "content_type"
+	InboxRuleFieldHeader        = "header"
+)
+
+const (
+	InboxRuleActionMove  = "move"
+	InboxRuleActionLabel = "label"
+	InboxRuleActionDrop  = "drop"
+)
+
+type InboxRule struct {
+	Identifier            string `json:"identifier" db:"identifier"`
+	ActorAddress          string `json:"actor_address" db:"actor_address"`
+	NodeIdentifier        string `json:"node_identifier" db:"node_identifier"`
+	InboxIdentifier       string `json:"inbox_identifier" db:"inbox_identifier"`
+	Position              int64  `json:"position" db:"position"`
+	Field                 string `json:"field" db:"field"`
+	Header                string `json:"header" db:"header"`
+	Pattern               string `json:"pattern" db:"pattern"`
+	Action                string `json:"action" db:"action"`
+	TargetInboxIdentifier string `json:"target_inbox_identifier" db:"target_inbox_identifier"`
+	Label                 string `json:"label" db:"label"`
+	CreatedAt             int64  `json:"created_at" db:"created_at"`
+	UpdatedAt             int64  `json:"updated_at" db:"updated_at"`
+}
+
+const (
+	DeliveryStatusPending    = "pending"
+	DeliveryStatusProcessing = "processing"
+	DeliveryStatusDelivered  = "delivered"
+	DeliveryStatusRejected   = "rejected"
+	DeliveryStatusDead       = "dead"
+	DeliveryStatusCancelled  = "cancelled"
+)
+
+const (
+	DeliveryKindMessage = "message"
+	DeliveryKindReceipt = "receipt"
+)
+
+type Delivery struct {
+	Identifier        string `json:"identifier" db:"identifier"`
+	MessageIdentifier string `json:"message_identifier" db:"message_identifier"`
+	OutboxIdentifier  string `json:"outbox_identifier" db:"outbox_identifier"`
+	NodeIdentifier    string `json:"node_identifier" db:"node_identifier"`
+	SenderAddress     string `json:"sender_address" db:"sender_address"`
+	RecipientAddress  string `json:"recipient_address" db:"recipient_address"`
+	DestinationVertex string `json:"destination_vertex" db:"destination_vertex"`
+	Revision          int64  `json:"revision" db:"revision"`
+	Kind              string `json:"kind" db:"kind"`
+	Status            string `json:"status" db:"status"`
+	Attempts          int64  `json:"attempts" db:"attempts"`
+	LastError         string `json:"last_error" db:"last_error"`
+	NextAttemptAt     int64  `json:"next_attempt_at" db:"next_attempt_at"`
+	ReadAt            int64  `json:"read_at" db:"read_at"`
+	CreatedAt         int64  `json:"created_at" db:"created_at"`
+	UpdatedAt         int64  `json:"updated_at" db:"updated_at"`
 }
+
+type MessageRevision struct {
+	MessageIdentifier   string            `json:"message_identifier" db:"message_identifier"`
+	BoxType             string            `json:"box_type" db:"box_type"`
+	BoxIdentifier       string            `json:"box_identifier" db:"box_identifier"`
+	NodeIdentifier      string            `json:"node_identifier" db:"node_identifier"`
+	Revision            int64             `json:"revision" db:"revision"`
+	ContentType         string            `json:"content_type" db:"content_type"`
+	Content             string            `json:"content" db:"content"`
+	Encryption          string            `json:"encryption" db:"encryption"`
+	EncryptionPublicKey string            `json:"encryption_public_key" db:"encryption_public_key"`
+	Attachments         []*Attachment     `json:"attachments" db:"attachments"`
+	Headers             map[string]string `json:"headers" db:"headers"`
+	Signature           string            `json:"signature" db:"signature"`
+	ReplacedAt          int64             `json:"replaced_at" db:"replaced_at"`
+}
+
+func (m *Message) GetRevision() *MessageRevision {
+	return &MessageRevision{
+		MessageIdentifier:   m.Identifier,
+		BoxType:             m.BoxType,
+		BoxIdentifier:       m.BoxIdentifier,
+		NodeIdentifier:      m.NodeIdentifier,
+		Revision:            m.Revision,
+		ContentType:         m.ContentType,
+		Content:             m.Content,
+		Encryption:          m.Encryption,
+		EncryptionPublicKey: m.EncryptionPublicKey,
+		Attachments:         m.Attachments,
+		Headers:             m.Headers,
+		Signature:           m.Signature,
+		ReplacedAt:          time.Now().UnixNano(),
+	}
+}
+
+const (
+	WebhookEventMessageReceived = "message.received"
+	WebhookEventMessageRead     = "message.read"
+	WebhookEventDeliveryFailed  = "delivery.failed"
+)
+
+func isValidWebhookEvent(event string) bool {
+	return event == WebhookEventMessageReceived || event == WebhookEventMessageRead || event == WebhookEventDeliveryFailed
+}
+
+type Webhook struct {
+	Identifier      string   `json:"identifier" db:"identifier"`
+	ActorAddress    string   `json:"actor_address" db:"actor_address"`
+	NodeIdentifier  string   `json:"node_identifier" db:"node_identifier"`
+	InboxIdentifier string   `json:"inbox_identifier" db:"inbox_identifier"`
+	URL             string   `json:"url" db:"url"`
+	Events          []string `json:"events" db:"events"`
+	Secret          string   `json:"-" db:"secret"`
+	Enabled         bool     `json:"enabled" db:"enabled"`
+	CreatedAt       int64    `json:"created_at" db:"created_at"`
+	UpdatedAt       int64    `json:"updated_at" db:"updated_at"`
+}
+
+func (w *Webhook) subscribes(event string, inboxIdentifier string) bool {
+	if !w.Enabled {
+		return false
+	}
+
+	if w.InboxIdentifier != "" && inboxIdentifier != "" && w.InboxIdentifier != inboxIdentifier {
+		return false
+	}
+
+	return slices.Contains(w.Events, event)
+}
+
+const (
+	WebhookDeliveryStatusPending    = "pending"
+	WebhookDeliveryStatusProcessing = "processing"
+	WebhookDeliveryStatusDelivered  = "delivered"
+	WebhookDeliveryStatusFailed     = "failed"
+)
+
+type WebhookDelivery struct {
+	Identifier        string `json:"identifier" db:"identifier"`
+	WebhookIdentifier string `json:"webhook_identifier" db:"webhook_identifier"`
+	Event             string `json:"event" db:"event"`
+	Payload           string `json:"payload" db:"payload"`
+	Status            string `json:"status" db:"status"`
+	Attempts          int64  `json:"attempts" db:"attempts"`
+	StatusCode        int    `json:"status_code" db:"status_code"`
+	LastError         string `json:"last_error" db:"last_error"`
+	NextAttemptAt     int64  `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt         int64  `json:"created_at" db:"created_at"`
+	UpdatedAt         int64  `json:"updated_at" db:"updated_at"`
+}
+
+type WebhookPayload struct {
+	Identifier        string      `json:"id"`
+	Event             string      `json:"event"`
+	WebhookIdentifier string      `json:"webhook_identifier"`
+	ActorAddress      string      `json:"actor_address"`
+	InboxIdentifier   string      `json:"inbox_identifier,omitempty"`
+	Data              interface{} `json:"data"`
+	CreatedAt         int64       `json:"created_at"`
+}
+
+type DeliveryBackoff struct {
+	Vertex        string `json:"vertex" db:"vertex"`
+	Failures      int64  `json:"failures" db:"failures"`
+	NextAttemptAt int64  `json:"next_attempt_at" db:"next_attempt_at"`
+	UpdatedAt     int64  `json:"updated_at" db:"updated_at"`
+}
+
+const (
+	InboxEventTypeMessage    = "message"
+	InboxEventTypeState      = "state"
+	InboxEventTypeDeletion   = "deletion"
+	InboxEventTypeRevision   = "revision"
+	InboxEventTypeRetraction = "retraction"
+)
+
+type InboxEvent struct {
+	Sequence          int64  `json:"sequence" db:"sequence"`
+	BoxType           string `json:"box_type" db:"box_type"`
+	InboxIdentifier   string `json:"inbox_identifier" db:"inbox_identifier"`
+	NodeIdentifier    string `json:"node_identifier" db:"node_identifier"`
+	Type              string `json:"type" db:"type"`
+	MessageIdentifier string `json:"message_identifier" db:"message_identifier"`
+	Data              string `json:"data" db:"data"`
+	CreatedAt         int64  `json:"created_at" db:"created_at"`
+}
+
+const (
+	SessionFrameTypeSubscribe   = "subscribe"
+	SessionFrameTypeUnsubscribe = "unsubscribe"
+	SessionFrameTypeSend        = "send"
+	SessionFrameTypePing        = "ping"
+	SessionFrameTypePong        = "pong"
+	SessionFrameTypeAck         = "ack"
+	SessionFrameTypeError       = "error"
+	SessionFrameTypeEvent       = "event"
+)
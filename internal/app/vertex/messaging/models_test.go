@@ -0,0 +1,35 @@
+package messaging
+
+import "testing"
+
+func TestMessageIsDuplicateOf(t *testing.T) {
+	original := &Message{
+		Identifier:    "message",
+		SenderAddress: "sender.example/node/alice",
+		ContentType:   "text/plain",
+		Content:       "hello",
+		Signature:     "signature",
+		CreatedAt:     1,
+	}
+
+	tests := []struct {
+		name      string
+		stored    func(message Message) Message
+		duplicate bool
+	}{
+		{"identical", func(message Message) Message { return message }, true},
+		{"different content", func(message Message) Message { message.Content = "bye"; return message }, false},
+		{"different sender", func(message Message) Message { message.SenderAddress = "other.example/node/mallory"; return message }, false},
+		{"different creation time", func(message Message) Message { message.CreatedAt = 2; return message }, false},
+		{"revised since delivery", func(message Message) Message { message.Content = "edited"; message.Revision = 1; return message }, true},
+		{"different state", func(message Message) Message { message.State = MessageStateRead; return message }, true},
+	}
+
+	for _, test := range tests {
+		stored := test.stored(*original)
+
+		if duplicate := stored.isDuplicateOf(original); duplicate != test.duplicate {
+			t.Errorf("%s: expected duplicate=%t, got %t", test.name, test.duplicate, duplicate)
+		}
+	}
+}
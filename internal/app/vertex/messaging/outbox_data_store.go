@@ -3,6 +3,7 @@ package messaging
 import (
 	"context"
 	"database/sql"
+	"github.com/evernetproto/evernet/internal/pkg/api"
 	"go.uber.org/zap"
 )
 
@@ -26,8 +27,9 @@ func (d *OutboxDataStore) Insert(ctx context.Context, outbox *Outbox) (*Outbox,
 	return outbox, err
 }
 
-func (d *OutboxDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Context, actorAddress string, nodeIdentifier string, page int64, size int64) ([]*Outbox, error) {
-	rows, err := d.db.QueryContext(ctx, "SELECT * FROM outboxes WHERE actor_address = ? and node_identifier = ? LIMIT ? OFFSET ?", actorAddress, nodeIdentifier, size, page*size)
+func (d *OutboxDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Context, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Outbox, error) {
+	createdAt, identifier := pagination.Bounds(false)
+	rows, err := d.db.QueryContext(ctx, "SELECT identifier, display_name, node_identifier, actor_address, retention_max_age, retention_max_count, created_at, updated_at FROM outboxes WHERE actor_address = ? and node_identifier = ? AND (created_at, identifier) > (?, ?) ORDER BY created_at, identifier LIMIT ? OFFSET ?", actorAddress, nodeIdentifier, createdAt, identifier, pagination.Size, pagination.Offset())
 
 	if err != nil {
 		return nil, err
@@ -43,7 +45,7 @@ func (d *OutboxDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Contex
 	var outboxes []*Outbox
 	for rows.Next() {
 		var outbox Outbox
-		err = rows.Scan(&outbox.Identifier, &outbox.DisplayName, &outbox.NodeIdentifier, &outbox.ActorAddress, &outbox.CreatedAt, &outbox.UpdatedAt)
+		err = rows.Scan(&outbox.Identifier, &outbox.DisplayName, &outbox.NodeIdentifier, &outbox.ActorAddress, &outbox.RetentionMaxAge, &outbox.RetentionMaxCount, &outbox.CreatedAt, &outbox.UpdatedAt)
 
 		if err != nil {
 			return nil, err
@@ -63,12 +65,14 @@ func (d *OutboxDataStore) FindByIdentifierAndActorAddressAndNodeIdentifier(ctx c
 	var outbox Outbox
 
 	err := d.db.QueryRowContext(ctx,
-		"SELECT identifier, display_name, node_identifier, actor_address, created_at, updated_at FROM outboxes WHERE identifier = ? AND actor_address = ? AND node_identifier = ?", identifier, actorAddress, nodeIdentifier).
+		"SELECT identifier, display_name, node_identifier, actor_address, retention_max_age, retention_max_count, created_at, updated_at FROM outboxes WHERE identifier = ? AND actor_address = ? AND node_identifier = ?", identifier, actorAddress, nodeIdentifier).
 		Scan(
 			&outbox.Identifier,
 			&outbox.DisplayName,
 			&outbox.NodeIdentifier,
 			&outbox.ActorAddress,
+			&outbox.RetentionMaxAge,
+			&outbox.RetentionMaxCount,
 			&outbox.CreatedAt,
 			&outbox.UpdatedAt,
 		)
@@ -100,6 +104,62 @@ func (d *OutboxDataStore) UpdateDisplayNameByIdentifierAndActorAddressAndNodeIde
 	return nil
 }
 
+func (d *OutboxDataStore) UpdateRetentionByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, retentionMaxAge int64, retentionMaxCount int64, identifier string, actorAddress string, nodeIdentifier string) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE outboxes SET retention_max_age = ?, retention_max_count = ? WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		retentionMaxAge, retentionMaxCount, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *OutboxDataStore) FindAllWithEffectiveRetention(ctx context.Context) ([]*Outbox, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT o.identifier, o.display_name, o.node_identifier, o.actor_address, CASE WHEN o.retention_max_age > 0 THEN o.retention_max_age ELSE n.retention_max_age END, CASE WHEN o.retention_max_count > 0 THEN o.retention_max_count ELSE n.retention_max_count END, o.created_at, o.updated_at FROM outboxes o JOIN nodes n ON n.identifier = o.node_identifier WHERE o.retention_max_age > 0 OR o.retention_max_count > 0 OR n.retention_max_age > 0 OR n.retention_max_count > 0")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var outboxes []*Outbox
+
+	for rows.Next() {
+		var outbox Outbox
+		err = rows.Scan(&outbox.Identifier, &outbox.DisplayName, &outbox.NodeIdentifier, &outbox.ActorAddress, &outbox.RetentionMaxAge, &outbox.RetentionMaxCount, &outbox.CreatedAt, &outbox.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		outboxes = append(outboxes, &outbox)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return outboxes, nil
+}
+
 func (d *OutboxDataStore) DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
 	result, err := d.db.ExecContext(ctx,
 		"DELETE FROM outboxes WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
@@ -3,6 +3,7 @@ package messaging
 import (
 	"context"
 	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/app/vertex/idempotency"
 	"github.com/evernetproto/evernet/internal/pkg/api"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -10,13 +11,14 @@ import (
 )
 
 type OutboxHandler struct {
-	router        *gin.Engine
-	authenticator *actor.Authenticator
-	manager       *OutboxManager
+	router             *gin.Engine
+	authenticator      *actor.Authenticator
+	manager            *OutboxManager
+	idempotencyManager *idempotency.Manager
 }
 
-func NewOutboxHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *OutboxManager) *OutboxHandler {
-	return &OutboxHandler{router: router, authenticator: authenticator, manager: manager}
+func NewOutboxHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *OutboxManager, idempotencyManager *idempotency.Manager) *OutboxHandler {
+	return &OutboxHandler{router: router, authenticator: authenticator, manager: manager, idempotencyManager: idempotencyManager}
 }
 
 func (h *OutboxHandler) Register() {
@@ -31,6 +33,10 @@ func (h *OutboxHandler) Register() {
 			return
 		}
 
+		if h.idempotencyManager.Replay(ctx, c, authenticatedActor.Address) {
+			return
+		}
+
 		var request OutboxCreationRequest
 		if err := c.ShouldBindJSON(&request); err != nil {
 			api.Error(c, http.StatusBadRequest, err)
@@ -57,16 +63,22 @@ func (h *OutboxHandler) Register() {
 			return
 		}
 
-		page, size := api.Page(c)
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
 
-		outboxes, err := h.manager.List(ctx, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, page, size)
+		outboxes, err := h.manager.List(ctx, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, pagination)
 
 		if err != nil {
 			api.Error(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, outboxes)
+		api.Paginated(c, http.StatusOK, pagination, outboxes, func(outbox *Outbox) *api.Cursor {
+			return &api.Cursor{CreatedAt: outbox.CreatedAt, Identifier: outbox.Identifier}
+		})
 	})
 
 	h.router.GET("/api/v1/messaging/outboxes/:outboxIdentifier", func(c *gin.Context) {
@@ -116,6 +128,32 @@ func (h *OutboxHandler) Register() {
 		api.Success(c, http.StatusOK, "outbox updated successfully")
 	})
 
+	h.router.PUT("/api/v1/messaging/outboxes/:outboxIdentifier/retention", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		outboxIdentifier := c.Param("outboxIdentifier")
+		var request OutboxRetentionUpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.UpdateRetention(ctx, outboxIdentifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "outbox retention updated successfully")
+	})
+
 	h.router.DELETE("/api/v1/messaging/outboxes/:outboxIdentifier", func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c, 5*time.Second)
 		defer cancel()
@@ -136,4 +174,84 @@ func (h *OutboxHandler) Register() {
 
 		api.Success(c, http.StatusOK, "outbox deleted successfully")
 	})
+
+	h.router.POST("/api/v1/messaging/outboxes/:outboxIdentifier/messages", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		if h.idempotencyManager.Replay(ctx, c, authenticatedActor.Address) {
+			return
+		}
+
+		outboxIdentifier := c.Param("outboxIdentifier")
+		var request MessageCreationRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		message, err := h.manager.Send(ctx, outboxIdentifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, message)
+	})
+
+	h.router.GET("/api/v1/messaging/outboxes/:outboxIdentifier/messages", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		outboxIdentifier := c.Param("outboxIdentifier")
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		messages, err := h.manager.ListMessages(ctx, outboxIdentifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, pagination)
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Paginated(c, http.StatusOK, pagination, messages, func(message *OutboxMessageResponse) *api.Cursor {
+			return &api.Cursor{CreatedAt: message.CreatedAt, Identifier: message.Identifier}
+		})
+	})
+
+	h.router.GET("/api/v1/messaging/outboxes/:outboxIdentifier/messages/:messageIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		outboxIdentifier := c.Param("outboxIdentifier")
+		messageIdentifier := c.Param("messageIdentifier")
+
+		message, err := h.manager.GetMessage(ctx, messageIdentifier, outboxIdentifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, message)
+	})
 }
@@ -5,15 +5,39 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/evernetproto/evernet/internal/pkg/ids"
+	"go.uber.org/zap"
+	"net/http"
 	"time"
 )
 
+const maxMessageRecipients = 100
+
 type OutboxManager struct {
-	dataStore *OutboxDataStore
+	vertex           string
+	dataStore        *OutboxDataStore
+	messageDataStore *MessageDataStore
+	inboxManager     *InboxManager
+	deliveryManager  *DeliveryManager
+	envelopeManager  *EnvelopeManager
+	threadManager    *ThreadManager
+	blobManager      *BlobManager
+	eventManager     *InboxEventManager
 }
 
-func NewOutboxManager(dataStore *OutboxDataStore) *OutboxManager {
-	return &OutboxManager{dataStore: dataStore}
+func NewOutboxManager(vertex string, dataStore *OutboxDataStore, messageDataStore *MessageDataStore, inboxManager *InboxManager, deliveryManager *DeliveryManager, envelopeManager *EnvelopeManager, threadManager *ThreadManager, blobManager *BlobManager, eventManager *InboxEventManager) *OutboxManager {
+	return &OutboxManager{
+		vertex:           vertex,
+		dataStore:        dataStore,
+		messageDataStore: messageDataStore,
+		inboxManager:     inboxManager,
+		deliveryManager:  deliveryManager,
+		envelopeManager:  envelopeManager,
+		threadManager:    threadManager,
+		blobManager:      blobManager,
+		eventManager:     eventManager,
+	}
 }
 
 func (m *OutboxManager) Create(ctx context.Context, request *OutboxCreationRequest, actorAddress string, nodeIdentifier string) (*Outbox, error) {
@@ -39,8 +63,8 @@ func (m *OutboxManager) Create(ctx context.Context, request *OutboxCreationReque
 	return m.dataStore.Insert(ctx, outbox)
 }
 
-func (m *OutboxManager) List(ctx context.Context, actorAddress string, nodeIdentifier string, page int64, size int64) ([]*Outbox, error) {
-	return m.dataStore.FindByActorAddressAndNodeIdentifier(ctx, actorAddress, nodeIdentifier, page, size)
+func (m *OutboxManager) List(ctx context.Context, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Outbox, error) {
+	return m.dataStore.FindByActorAddressAndNodeIdentifier(ctx, actorAddress, nodeIdentifier, pagination)
 }
 
 func (m *OutboxManager) Get(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*Outbox, error) {
@@ -64,6 +88,16 @@ func (m *OutboxManager) Update(ctx context.Context, identifier string, request *
 	return err
 }
 
+func (m *OutboxManager) UpdateRetention(ctx context.Context, identifier string, request *OutboxRetentionUpdateRequest, actorAddress string, nodeIdentifier string) error {
+	err := m.dataStore.UpdateRetentionByIdentifierAndActorAddressAndNodeIdentifier(ctx, request.MaxAge, request.MaxCount, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("outbox %s not found", identifier)
+	}
+
+	return err
+}
+
 func (m *OutboxManager) Delete(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
 	err := m.dataStore.DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
 
@@ -71,5 +105,302 @@ func (m *OutboxManager) Delete(ctx context.Context, identifier string, actorAddr
 		return fmt.Errorf("outbox %s not found", identifier)
 	}
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	err = m.messageDataStore.DeleteByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, BoxTypeOutbox, identifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.eventManager.DeleteOutbox(ctx, identifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	return m.blobManager.Release(ctx, BoxTypeOutbox, identifier, nodeIdentifier)
+}
+
+func (m *OutboxManager) Send(ctx context.Context, identifier string, request *MessageCreationRequest, actorAddress string, nodeIdentifier string) (*OutboxMessageResponse, error) {
+	outbox, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := parseRecipients(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateEncryption(request.Encryption, request.EncryptionPublicKey, request.Content, len(recipients))
+
+	if err != nil {
+		return nil, reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	err = m.blobManager.Validate(ctx, request.Attachments, outbox.ActorAddress, outbox.NodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	messageIdentifier, err := ids.Generate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	threadIdentifier := messageIdentifier
+
+	if request.InReplyTo != "" {
+		threadIdentifier, err = m.threadManager.Resolve(ctx, request.InReplyTo, outbox.ActorAddress, outbox.NodeIdentifier)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	message := &Message{
+		Identifier:          messageIdentifier,
+		BoxType:             BoxTypeOutbox,
+		BoxIdentifier:       outbox.Identifier,
+		NodeIdentifier:      outbox.NodeIdentifier,
+		ActorAddress:        outbox.ActorAddress,
+		SenderAddress:       outbox.ActorAddress,
+		RecipientAddress:    recipients[0].String(),
+		ContentType:         request.ContentType,
+		Content:             request.Content,
+		Encryption:          request.Encryption,
+		EncryptionPublicKey: request.EncryptionPublicKey,
+		ThreadIdentifier:    threadIdentifier,
+		InReplyTo:           request.InReplyTo,
+		Attachments:         request.Attachments,
+		Headers:             request.Headers,
+		CreatedAt:           time.Now().UnixNano(),
+		UpdatedAt:           time.Now().UnixNano(),
+	}
+
+	if len(recipients) > 1 {
+		message.RecipientAddress = ""
+
+		for _, recipient := range recipients {
+			message.RecipientAddresses = append(message.RecipientAddresses, recipient.String())
+		}
+	}
+
+	sender, err := ParseActorAddress(message.SenderAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sender.Vertex == m.vertex {
+		message.Signature, err = m.envelopeManager.Sign(ctx, message)
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, recipient := range recipients {
+			if recipient.Vertex != m.vertex {
+				return nil, fmt.Errorf("actor %s cannot send remote messages from vertex %s", message.SenderAddress, m.vertex)
+			}
+		}
+	}
+
+	if len(recipients) == 1 {
+		return m.sendOne(ctx, message, recipients[0])
+	}
+
+	return m.fanOut(ctx, message, recipients)
+}
+
+func (m *OutboxManager) sendOne(ctx context.Context, message *Message, recipient *InboxAddress) (*OutboxMessageResponse, error) {
+	var deliveries []*Delivery
+
+	if recipient.Vertex == m.vertex {
+		_, err := m.inboxManager.Deliver(ctx, message)
+
+		if err != nil && !errors.Is(err, errMessageDropped) && !errors.Is(err, errMessageDuplicate) {
+			return nil, err
+		}
+	}
+
+	message, err := m.messageDataStore.Insert(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.blobManager.Reference(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if recipient.Vertex == m.vertex {
+		delivery, err := m.deliveryManager.RecordDelivered(ctx, message, recipient)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	} else {
+		deliveries, err = m.deliveryManager.Enqueue(ctx, message, []*InboxAddress{recipient})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &OutboxMessageResponse{Message: message, Deliveries: deliveries}, nil
+}
+
+func (m *OutboxManager) fanOut(ctx context.Context, message *Message, recipients []*InboxAddress) (*OutboxMessageResponse, error) {
+	message, err := m.messageDataStore.Insert(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.blobManager.Reference(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*Delivery
+	var remoteRecipients []*InboxAddress
+
+	for _, recipient := range recipients {
+		if recipient.Vertex != m.vertex {
+			remoteRecipients = append(remoteRecipients, recipient)
+			continue
+		}
+
+		var delivery *Delivery
+
+		_, deliveryErr := m.inboxManager.Deliver(ctx, message.addressedTo(recipient.String()))
+
+		if deliveryErr != nil && !errors.Is(deliveryErr, errMessageDropped) && !errors.Is(deliveryErr, errMessageDuplicate) {
+			zap.L().Warn("local delivery failed", zap.String("message", message.Identifier), zap.String("recipient", recipient.String()), zap.Error(deliveryErr))
+			delivery, err = m.deliveryManager.RecordRejected(ctx, message, recipient, deliveryErr)
+		} else {
+			delivery, err = m.deliveryManager.RecordDelivered(ctx, message, recipient)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if len(remoteRecipients) > 0 {
+		remoteDeliveries, err := m.deliveryManager.Enqueue(ctx, message, remoteRecipients)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, remoteDeliveries...)
+	}
+
+	return &OutboxMessageResponse{Message: message, Deliveries: deliveries}, nil
+}
+
+func parseRecipients(request *MessageCreationRequest) ([]*InboxAddress, error) {
+	addresses := request.RecipientAddresses
+
+	if request.RecipientAddress != "" {
+		addresses = append([]string{request.RecipientAddress}, addresses...)
+	}
+
+	if len(addresses) == 0 {
+		return nil, reject(http.StatusBadRequest, "recipient_address or recipient_addresses is required")
+	}
+
+	var recipients []*InboxAddress
+	seen := make(map[string]bool)
+
+	for _, address := range addresses {
+		recipient, err := ParseInboxAddress(address)
+
+		if err != nil {
+			return nil, reject(http.StatusBadRequest, "%s", err.Error())
+		}
+
+		if seen[recipient.String()] {
+			continue
+		}
+
+		seen[recipient.String()] = true
+		recipients = append(recipients, recipient)
+	}
+
+	if len(recipients) > maxMessageRecipients {
+		return nil, reject(http.StatusBadRequest, "a message can have at most %d recipients", maxMessageRecipients)
+	}
+
+	return recipients, nil
+}
+
+func (m *OutboxManager) ListMessages(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*OutboxMessageResponse, error) {
+	outbox, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := m.messageDataStore.FindByBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, BoxTypeOutbox, outbox.Identifier, outbox.NodeIdentifier, pagination)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*OutboxMessageResponse
+
+	for _, message := range messages {
+		deliveries, err := m.deliveryManager.ListByMessage(ctx, message)
+
+		if err != nil {
+			return nil, err
+		}
+
+		responses = append(responses, &OutboxMessageResponse{Message: message, Deliveries: deliveries})
+	}
+
+	return responses, nil
+}
+
+func (m *OutboxManager) GetMessage(ctx context.Context, messageIdentifier string, identifier string, actorAddress string, nodeIdentifier string) (*OutboxMessageResponse, error) {
+	outbox, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := m.messageDataStore.FindByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, messageIdentifier, BoxTypeOutbox, outbox.Identifier, outbox.NodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("message %s not found", messageIdentifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := m.deliveryManager.ListByMessage(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxMessageResponse{Message: message, Deliveries: deliveries}, nil
 }
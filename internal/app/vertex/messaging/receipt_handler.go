@@ -0,0 +1,49 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type ReceiptHandler struct {
+	router        *gin.Engine
+	authenticator *actor.Authenticator
+	manager       *ReceiptManager
+}
+
+func NewReceiptHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *ReceiptManager) *ReceiptHandler {
+	return &ReceiptHandler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *ReceiptHandler) Register() {
+
+	h.router.POST("/api/v1/messaging/receipts", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		var request ReceiptRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.Receive(ctx, &request, authenticatedActor.Address, authenticatedActor.TargetNodeAddress)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Success(c, http.StatusCreated, "receipt recorded successfully")
+	})
+}
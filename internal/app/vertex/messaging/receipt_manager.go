@@ -0,0 +1,62 @@
+package messaging
+
+import (
+	"context"
+	"net/http"
+)
+
+type ReceiptManager struct {
+	vertex          string
+	deliveryManager *DeliveryManager
+}
+
+func NewReceiptManager(vertex string, deliveryManager *DeliveryManager) *ReceiptManager {
+	return &ReceiptManager{vertex: vertex, deliveryManager: deliveryManager}
+}
+
+func (m *ReceiptManager) Send(ctx context.Context, message *Message, readAt int64) error {
+	receipt := &ReceiptRequest{
+		MessageIdentifier: message.Identifier,
+		SenderAddress:     message.SenderAddress,
+		RecipientAddress:  message.RecipientAddress,
+		ReadAt:            readAt,
+	}
+
+	sender, err := ParseActorAddress(receipt.SenderAddress)
+
+	if err != nil {
+		return err
+	}
+
+	if sender.Vertex == m.vertex {
+		return m.deliveryManager.RecordRead(ctx, receipt)
+	}
+
+	_, err = m.deliveryManager.EnqueueReceipt(ctx, receipt, message.NodeIdentifier)
+
+	return err
+}
+
+func (m *ReceiptManager) Receive(ctx context.Context, request *ReceiptRequest, recipientActorAddress string, targetNodeAddress string) error {
+	recipient, err := ParseInboxAddress(request.RecipientAddress)
+
+	if err != nil {
+		return reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	if recipient.GetActorAddress() != recipientActorAddress {
+		return reject(http.StatusForbidden, "recipient %s does not match authenticated actor", request.RecipientAddress)
+	}
+
+	sender, err := ParseActorAddress(request.SenderAddress)
+
+	if err != nil {
+		return reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	if sender.Vertex != m.vertex || sender.GetNodeAddress() != targetNodeAddress {
+		return reject(http.StatusForbidden, "sender %s does not match token audience", request.SenderAddress)
+	}
+
+	return m.deliveryManager.RecordRead(ctx, request)
+}
@@ -0,0 +1,52 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/discovery"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
+)
+
+type RemoteInboxManager struct {
+	federationClient *federation.Client
+	discoveryManager *discovery.RemoteManager
+}
+
+func NewRemoteInboxManager(federationClient *federation.Client, discoveryManager *discovery.RemoteManager) *RemoteInboxManager {
+	return &RemoteInboxManager{federationClient: federationClient, discoveryManager: discoveryManager}
+}
+
+func (m *RemoteInboxManager) Deliver(ctx context.Context, vertex string, token string, idempotencyKey string, request *DeliveryRequest) error {
+	url, err := m.discoveryManager.URL(ctx, vertex, discovery.EndpointDeliveries)
+
+	if err != nil {
+		return err
+	}
+
+	return m.federationClient.PostIdempotent(ctx, url, token, idempotencyKey, request, nil)
+}
+
+func (m *RemoteInboxManager) DeliverBatch(ctx context.Context, vertex string, token string, idempotencyKey string, request *DeliveryBatchRequest) (*DeliveryBatchResponse, error) {
+	return m.deliverBatch(ctx, vertex, discovery.EndpointDeliveryBatches, token, idempotencyKey, request)
+}
+
+func (m *RemoteInboxManager) DeliverRevision(ctx context.Context, vertex string, token string, idempotencyKey string, request *DeliveryBatchRequest) (*DeliveryBatchResponse, error) {
+	return m.deliverBatch(ctx, vertex, discovery.EndpointDeliveryRevisions, token, idempotencyKey, request)
+}
+
+func (m *RemoteInboxManager) deliverBatch(ctx context.Context, vertex string, endpoint string, token string, idempotencyKey string, request *DeliveryBatchRequest) (*DeliveryBatchResponse, error) {
+	url, err := m.discoveryManager.URL(ctx, vertex, endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var response DeliveryBatchResponse
+
+	err = m.federationClient.PostIdempotent(ctx, url, token, idempotencyKey, request, &response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
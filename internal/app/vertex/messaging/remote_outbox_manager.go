@@ -0,0 +1,26 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/discovery"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
+)
+
+type RemoteOutboxManager struct {
+	federationClient *federation.Client
+	discoveryManager *discovery.RemoteManager
+}
+
+func NewRemoteOutboxManager(federationClient *federation.Client, discoveryManager *discovery.RemoteManager) *RemoteOutboxManager {
+	return &RemoteOutboxManager{federationClient: federationClient, discoveryManager: discoveryManager}
+}
+
+func (m *RemoteOutboxManager) Receipt(ctx context.Context, vertex string, token string, request *ReceiptRequest) error {
+	url, err := m.discoveryManager.URL(ctx, vertex, discovery.EndpointReceipts)
+
+	if err != nil {
+		return err
+	}
+
+	return m.federationClient.Post(ctx, url, token, request, nil)
+}
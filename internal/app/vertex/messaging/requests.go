@@ -1,14 +1,60 @@
 package messaging
 
 type InboxCreationRequest struct {
-	Identifier  string `json:"identifier" binding:"required"`
-	DisplayName string `json:"display_name" binding:"required"`
+	Identifier   string `json:"identifier" binding:"required"`
+	DisplayName  string `json:"display_name" binding:"required"`
+	ReadReceipts bool   `json:"read_receipts"`
+	Policy       string `json:"policy"`
 }
 
 type InboxUpdateRequest struct {
 	DisplayName string `json:"display_name" binding:"required"`
 }
 
+type InboxReadReceiptsUpdateRequest struct {
+	ReadReceipts *bool `json:"read_receipts" binding:"required"`
+}
+
+type InboxRetentionUpdateRequest struct {
+	MaxAge   int64 `json:"max_age" binding:"min=0"`
+	MaxCount int64 `json:"max_count" binding:"min=0"`
+}
+
+type InboxPolicyUpdateRequest struct {
+	Policy string `json:"policy" binding:"required"`
+}
+
+type InboxAccessEntryRequest struct {
+	Entry string `json:"entry" binding:"required"`
+}
+
+type MessageStateUpdateRequest struct {
+	State string `json:"state" binding:"required"`
+}
+
+type MessageStateBatchUpdateRequest struct {
+	MessageIdentifiers []string `json:"message_identifiers" binding:"required"`
+	State              string   `json:"state" binding:"required"`
+}
+
+type InboxRuleRequest struct {
+	InboxIdentifier       string `json:"inbox_identifier"`
+	Position              *int64 `json:"position"`
+	Field                 string `json:"field" binding:"required"`
+	Header                string `json:"header"`
+	Pattern               string `json:"pattern" binding:"required"`
+	Action                string `json:"action" binding:"required"`
+	TargetInboxIdentifier string `json:"target_inbox_identifier"`
+	Label                 string `json:"label"`
+}
+
+type WebhookRequest struct {
+	InboxIdentifier string   `json:"inbox_identifier"`
+	URL             string   `json:"url" binding:"required,url"`
+	Events          []string `json:"events" binding:"required,min=1"`
+	Enabled         *bool    `json:"enabled"`
+}
+
 type OutboxCreationRequest struct {
 	Identifier  string `json:"identifier" binding:"required"`
 	DisplayName string `json:"display_name" binding:"required"`
@@ -17,3 +63,78 @@ type OutboxCreationRequest struct {
 type OutboxUpdateRequest struct {
 	DisplayName string `json:"display_name" binding:"required"`
 }
+
+type OutboxRetentionUpdateRequest struct {
+	MaxAge   int64 `json:"max_age" binding:"min=0"`
+	MaxCount int64 `json:"max_count" binding:"min=0"`
+}
+
+type MessageCreationRequest struct {
+	RecipientAddress    string            `json:"recipient_address"`
+	RecipientAddresses  []string          `json:"recipient_addresses"`
+	ContentType         string            `json:"content_type" binding:"required"`
+	Content             string            `json:"content" binding:"required"`
+	Encryption          string            `json:"encryption"`
+	EncryptionPublicKey string            `json:"encryption_public_key"`
+	InReplyTo           string            `json:"in_reply_to"`
+	Attachments         []*Attachment     `json:"attachments" binding:"dive"`
+	Headers             map[string]string `json:"headers"`
+}
+
+type MessageEditRequest struct {
+	ContentType         string            `json:"content_type" binding:"required"`
+	Content             string            `json:"content" binding:"required"`
+	Encryption          string            `json:"encryption"`
+	EncryptionPublicKey string            `json:"encryption_public_key"`
+	Attachments         []*Attachment     `json:"attachments" binding:"dive"`
+	Headers             map[string]string `json:"headers"`
+}
+
+type DeliveryRequest struct {
+	Identifier          string            `json:"identifier" binding:"required"`
+	SenderAddress       string            `json:"sender_address" binding:"required"`
+	RecipientAddress    string            `json:"recipient_address"`
+	RecipientAddresses  []string          `json:"recipient_addresses,omitempty"`
+	ContentType         string            `json:"content_type"`
+	Content             string            `json:"content"`
+	Encryption          string            `json:"encryption"`
+	EncryptionPublicKey string            `json:"encryption_public_key"`
+	ThreadIdentifier    string            `json:"thread_identifier"`
+	InReplyTo           string            `json:"in_reply_to"`
+	Attachments         []*Attachment     `json:"attachments" binding:"dive"`
+	Headers             map[string]string `json:"headers"`
+	Revision            int64             `json:"revision,omitempty"`
+	Retracted           bool              `json:"retracted,omitempty"`
+	Signature           string            `json:"signature" binding:"required"`
+	CreatedAt           int64             `json:"created_at" binding:"required"`
+}
+
+type DeliveryBatchRequest struct {
+	Message            *DeliveryRequest `json:"message" binding:"required"`
+	RecipientAddresses []string         `json:"recipient_addresses" binding:"required,min=1"`
+}
+
+type ReceiptRequest struct {
+	MessageIdentifier string `json:"message_identifier" binding:"required"`
+	SenderAddress     string `json:"sender_address" binding:"required"`
+	RecipientAddress  string `json:"recipient_address" binding:"required"`
+	ReadAt            int64  `json:"read_at" binding:"required"`
+}
+
+type SessionRequest struct {
+	Type                string                  `json:"type"`
+	RequestIdentifier   string                  `json:"request_id"`
+	InboxIdentifier     string                  `json:"inbox_identifier"`
+	OutboxIdentifier    string                  `json:"outbox_identifier"`
+	LastEventIdentifier int64                   `json:"last_event_id"`
+	Message             *MessageCreationRequest `json:"message"`
+}
+
+type MessageSearchRequest struct {
+	Query           string `form:"q" binding:"required"`
+	InboxIdentifier string `form:"inbox"`
+	SenderAddress   string `form:"sender"`
+	From            int64  `form:"from"`
+	To              int64  `form:"to"`
+	Label           string `form:"label"`
+}
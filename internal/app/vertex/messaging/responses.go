@@ -0,0 +1,44 @@
+package messaging
+
+type InboxResponse struct {
+	*Inbox
+	UnreadCount int64 `json:"unread_count"`
+}
+
+type OutboxMessageResponse struct {
+	*Message
+	Deliveries []*Delivery `json:"deliveries"`
+}
+
+type WebhookSecretResponse struct {
+	*Webhook
+	Secret string `json:"secret"`
+}
+
+type DeliveryResult struct {
+	RecipientAddress string `json:"recipient_address"`
+	Status           string `json:"status"`
+	StatusCode       int    `json:"status_code,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+type DeliveryBatchResponse struct {
+	Results []*DeliveryResult `json:"results"`
+}
+
+type SessionResponse struct {
+	Type              string      `json:"type"`
+	RequestIdentifier string      `json:"request_id,omitempty"`
+	InboxIdentifier   string      `json:"inbox_identifier,omitempty"`
+	Event             *InboxEvent `json:"event,omitempty"`
+	Data              interface{} `json:"data,omitempty"`
+	Message           string      `json:"message,omitempty"`
+}
+
+type MessageVerificationResponse struct {
+	Identifier    string `json:"identifier"`
+	SenderAddress string `json:"sender_address"`
+	Signature     string `json:"signature"`
+	Verified      bool   `json:"verified"`
+	Message       string `json:"message,omitempty"`
+}
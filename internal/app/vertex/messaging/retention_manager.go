@@ -0,0 +1,215 @@
+package messaging
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+const retentionBatchSize = 500
+
+type RetentionManager struct {
+	interval          time.Duration
+	inboxDataStore    *InboxDataStore
+	outboxDataStore   *OutboxDataStore
+	messageDataStore  *MessageDataStore
+	deliveryDataStore *DeliveryDataStore
+	eventManager      *InboxEventManager
+	blobManager       *BlobManager
+}
+
+func NewRetentionManager(interval time.Duration, inboxDataStore *InboxDataStore, outboxDataStore *OutboxDataStore, messageDataStore *MessageDataStore, deliveryDataStore *DeliveryDataStore, eventManager *InboxEventManager, blobManager *BlobManager) *RetentionManager {
+	return &RetentionManager{
+		interval:          interval,
+		inboxDataStore:    inboxDataStore,
+		outboxDataStore:   outboxDataStore,
+		messageDataStore:  messageDataStore,
+		deliveryDataStore: deliveryDataStore,
+		eventManager:      eventManager,
+		blobManager:       blobManager,
+	}
+}
+
+func (m *RetentionManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := m.Enforce(ctx)
+
+		if err != nil {
+			zap.L().Error("error enforcing retention", zap.Error(err))
+		}
+	}
+}
+
+func (m *RetentionManager) Enforce(ctx context.Context) error {
+	inboxes, err := m.inboxDataStore.FindAllWithEffectiveRetention(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, inbox := range inboxes {
+		err = m.enforce(ctx, BoxTypeInbox, inbox.Identifier, inbox.NodeIdentifier, inbox.RetentionMaxAge, inbox.RetentionMaxCount, func(messages []*Message) (int64, error) {
+			return m.expireInbox(ctx, inbox, messages)
+		})
+
+		if err != nil {
+			zap.L().Error("error enforcing inbox retention", zap.String("inbox", inbox.Identifier), zap.String("node", inbox.NodeIdentifier), zap.Error(err))
+		}
+	}
+
+	outboxes, err := m.outboxDataStore.FindAllWithEffectiveRetention(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, outbox := range outboxes {
+		err = m.enforce(ctx, BoxTypeOutbox, outbox.Identifier, outbox.NodeIdentifier, outbox.RetentionMaxAge, outbox.RetentionMaxCount, func(messages []*Message) (int64, error) {
+			return m.expireOutbox(ctx, outbox, messages)
+		})
+
+		if err != nil {
+			zap.L().Error("error enforcing outbox retention", zap.String("outbox", outbox.Identifier), zap.String("node", outbox.NodeIdentifier), zap.Error(err))
+		}
+	}
+
+	for {
+		collected, err := m.blobManager.CollectOrphans(ctx, retentionBatchSize)
+
+		if err != nil {
+			return err
+		}
+
+		if collected < retentionBatchSize {
+			return nil
+		}
+	}
+}
+
+func (m *RetentionManager) enforce(ctx context.Context, boxType string, boxIdentifier string, nodeIdentifier string, maxAge int64, maxCount int64, expire func(messages []*Message) (int64, error)) error {
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-time.Duration(maxAge) * time.Second).UnixNano()
+		skipped := int64(0)
+
+		for {
+			messages, err := m.messageDataStore.FindByBoxTypeAndBoxIdentifierAndNodeIdentifierAndCreatedAtBefore(ctx, boxType, boxIdentifier, nodeIdentifier, cutoff, skipped, retentionBatchSize)
+
+			if err != nil {
+				return err
+			}
+
+			expired, err := expire(messages)
+
+			if err != nil {
+				return err
+			}
+
+			skipped += int64(len(messages)) - expired
+
+			if len(messages) < retentionBatchSize {
+				break
+			}
+		}
+	}
+
+	if maxCount > 0 {
+		skipped := int64(0)
+
+		for {
+			messages, err := m.messageDataStore.FindByBoxTypeAndBoxIdentifierAndNodeIdentifierWithOffset(ctx, boxType, boxIdentifier, nodeIdentifier, maxCount+skipped, retentionBatchSize)
+
+			if err != nil {
+				return err
+			}
+
+			expired, err := expire(messages)
+
+			if err != nil {
+				return err
+			}
+
+			skipped += int64(len(messages)) - expired
+
+			if len(messages) < retentionBatchSize {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *RetentionManager) expireInbox(ctx context.Context, inbox *Inbox, messages []*Message) (int64, error) {
+	for _, message := range messages {
+		err := m.messageDataStore.DeleteByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message.Identifier, message.BoxType, message.BoxIdentifier, message.NodeIdentifier)
+
+		if err != nil {
+			return 0, err
+		}
+
+		err = m.blobManager.ReleaseMessage(ctx, message)
+
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = m.eventManager.Tombstone(ctx, inbox, message)
+
+		if err != nil {
+			return 0, err
+		}
+
+		zap.L().Debug("expired message", zap.String("inbox", inbox.Identifier), zap.String("message", message.Identifier))
+	}
+
+	return int64(len(messages)), nil
+}
+
+func (m *RetentionManager) expireOutbox(ctx context.Context, outbox *Outbox, messages []*Message) (int64, error) {
+	expired := int64(0)
+
+	for _, message := range messages {
+		pending, err := m.deliveryDataStore.ExistsByMessageIdentifierAndOutboxIdentifierAndNodeIdentifierAndStatusIn(ctx, message.Identifier, outbox.Identifier, outbox.NodeIdentifier, []string{DeliveryStatusPending, DeliveryStatusProcessing})
+
+		if err != nil {
+			return expired, err
+		}
+
+		if pending {
+			continue
+		}
+
+		err = m.messageDataStore.DeleteByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message.Identifier, message.BoxType, message.BoxIdentifier, message.NodeIdentifier)
+
+		if err != nil {
+			return expired, err
+		}
+
+		err = m.blobManager.ReleaseMessage(ctx, message)
+
+		if err != nil {
+			return expired, err
+		}
+
+		_, err = m.eventManager.TombstoneOutbox(ctx, outbox, message)
+
+		if err != nil {
+			return expired, err
+		}
+
+		expired++
+
+		zap.L().Debug("expired message", zap.String("outbox", outbox.Identifier), zap.String("message", message.Identifier))
+	}
+
+	return expired, nil
+}
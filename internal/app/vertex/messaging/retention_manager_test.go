@@ -0,0 +1,89 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestRetentionKeepsOutboxMessagesWithPendingDeliveries(t *testing.T) {
+	ctx := context.Background()
+	database := newTestDatabase(t)
+	messageDataStore := NewMessageDataStore(database)
+	deliveryDataStore := NewDeliveryDataStore(database)
+	eventDataStore := NewInboxEventDataStore(database)
+	blobManager := NewBlobManager("local.example", t.TempDir(), NewBlobDataStore(database), nil, nil, nil, nil)
+	manager := NewRetentionManager(0, NewInboxDataStore(database), NewOutboxDataStore(database), messageDataStore, deliveryDataStore, NewInboxEventManager(eventDataStore), blobManager)
+
+	outbox := &Outbox{Identifier: "sent", NodeIdentifier: "node", ActorAddress: "local.example/node/owner"}
+
+	for i, identifier := range []string{"pending", "delivered", "latest"} {
+		_, err := messageDataStore.Insert(ctx, &Message{
+			Identifier:       identifier,
+			BoxType:          BoxTypeOutbox,
+			BoxIdentifier:    outbox.Identifier,
+			NodeIdentifier:   outbox.NodeIdentifier,
+			ActorAddress:     outbox.ActorAddress,
+			SenderAddress:    outbox.ActorAddress,
+			RecipientAddress: "remote.example/node/alice/main",
+			ContentType:      "text/plain",
+			Content:          identifier,
+			State:            MessageStateRead,
+			CreatedAt:        int64(i + 1),
+			UpdatedAt:        int64(i + 1),
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for identifier, status := range map[string]string{"pending": DeliveryStatusPending, "delivered": DeliveryStatusDelivered} {
+		_, err := deliveryDataStore.Insert(ctx, &Delivery{
+			Identifier:        identifier + "-delivery",
+			MessageIdentifier: identifier,
+			OutboxIdentifier:  outbox.Identifier,
+			NodeIdentifier:    outbox.NodeIdentifier,
+			SenderAddress:     outbox.ActorAddress,
+			RecipientAddress:  "remote.example/node/alice/main",
+			DestinationVertex: "remote.example",
+			Kind:              DeliveryKindMessage,
+			Status:            status,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := manager.enforce(ctx, BoxTypeOutbox, outbox.Identifier, outbox.NodeIdentifier, 0, 1, func(messages []*Message) (int64, error) {
+		return manager.expireOutbox(ctx, outbox, messages)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for identifier, exists := range map[string]bool{"pending": true, "delivered": false, "latest": true} {
+		_, err = messageDataStore.FindByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, identifier, BoxTypeOutbox, outbox.Identifier, outbox.NodeIdentifier)
+
+		if exists && err != nil {
+			t.Fatalf("expected %s to be kept: %v", identifier, err)
+		}
+
+		if !exists && !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("expected %s to be expired, got %v", identifier, err)
+		}
+	}
+
+	events, err := eventDataStore.FindByBoxTypeAndInboxIdentifierAndNodeIdentifierAndSequenceAfter(ctx, BoxTypeOutbox, outbox.Identifier, outbox.NodeIdentifier, 0, 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Type != InboxEventTypeDeletion || events[0].MessageIdentifier != "delivered" {
+		t.Fatalf("expected a single tombstone for the expired message, got %+v", events)
+	}
+}
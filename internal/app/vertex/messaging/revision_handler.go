@@ -0,0 +1,153 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/app/vertex/idempotency"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type RevisionHandler struct {
+	router             *gin.Engine
+	authenticator      *actor.Authenticator
+	manager            *RevisionManager
+	idempotencyManager *idempotency.Manager
+}
+
+func NewRevisionHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *RevisionManager, idempotencyManager *idempotency.Manager) *RevisionHandler {
+	return &RevisionHandler{router: router, authenticator: authenticator, manager: manager, idempotencyManager: idempotencyManager}
+}
+
+func (h *RevisionHandler) Register() {
+
+	h.router.PUT("/api/v1/messaging/outboxes/:outboxIdentifier/messages/:messageIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		var request MessageEditRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		outboxIdentifier := c.Param("outboxIdentifier")
+		messageIdentifier := c.Param("messageIdentifier")
+
+		message, err := h.manager.Edit(ctx, messageIdentifier, outboxIdentifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, message)
+	})
+
+	h.router.DELETE("/api/v1/messaging/outboxes/:outboxIdentifier/messages/:messageIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		outboxIdentifier := c.Param("outboxIdentifier")
+		messageIdentifier := c.Param("messageIdentifier")
+
+		message, err := h.manager.Retract(ctx, messageIdentifier, outboxIdentifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, message)
+	})
+
+	h.router.GET("/api/v1/messaging/outboxes/:outboxIdentifier/messages/:messageIdentifier/revisions", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		outboxIdentifier := c.Param("outboxIdentifier")
+		messageIdentifier := c.Param("messageIdentifier")
+
+		revisions, err := h.manager.ListOutboxRevisions(ctx, messageIdentifier, outboxIdentifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, revisions)
+	})
+
+	h.router.GET("/api/v1/messaging/inboxes/:inboxIdentifier/messages/:messageIdentifier/revisions", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		inboxIdentifier := c.Param("inboxIdentifier")
+		messageIdentifier := c.Param("messageIdentifier")
+
+		revisions, err := h.manager.ListInboxRevisions(ctx, messageIdentifier, inboxIdentifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, revisions)
+	})
+
+	h.router.POST("/api/v1/messaging/deliveries/revisions", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateFederatedContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		if h.idempotencyManager.Replay(ctx, c, authenticatedActor.Address) {
+			return
+		}
+
+		var request DeliveryBatchRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		response, err := h.manager.Receive(ctx, &request, authenticatedActor.Address, authenticatedActor.TargetNodeAddress)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	})
+}
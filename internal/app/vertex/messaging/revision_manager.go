@@ -0,0 +1,424 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type RevisionManager struct {
+	vertex           string
+	dataStore        *MessageRevisionDataStore
+	messageDataStore *MessageDataStore
+	inboxDataStore   *InboxDataStore
+	outboxManager    *OutboxManager
+	inboxManager     *InboxManager
+	deliveryManager  *DeliveryManager
+	envelopeManager  *EnvelopeManager
+	eventManager     *InboxEventManager
+	blobManager      *BlobManager
+}
+
+func NewRevisionManager(
+	vertex string,
+	dataStore *MessageRevisionDataStore,
+	messageDataStore *MessageDataStore,
+	inboxDataStore *InboxDataStore,
+	outboxManager *OutboxManager,
+	inboxManager *InboxManager,
+	deliveryManager *DeliveryManager,
+	envelopeManager *EnvelopeManager,
+	eventManager *InboxEventManager,
+	blobManager *BlobManager,
+) *RevisionManager {
+	return &RevisionManager{
+		vertex:           vertex,
+		dataStore:        dataStore,
+		messageDataStore: messageDataStore,
+		inboxDataStore:   inboxDataStore,
+		outboxManager:    outboxManager,
+		inboxManager:     inboxManager,
+		deliveryManager:  deliveryManager,
+		envelopeManager:  envelopeManager,
+		eventManager:     eventManager,
+		blobManager:      blobManager,
+	}
+}
+
+func (m *RevisionManager) Edit(ctx context.Context, messageIdentifier string, outboxIdentifier string, request *MessageEditRequest, actorAddress string, nodeIdentifier string) (*OutboxMessageResponse, error) {
+	message, err := m.getRevisableMessage(ctx, messageIdentifier, outboxIdentifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateEncryption(request.Encryption, request.EncryptionPublicKey, request.Content, len(message.RecipientAddresses))
+
+	if err != nil {
+		return nil, reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	err = m.blobManager.Validate(ctx, request.Attachments, message.ActorAddress, message.NodeIdentifier)
+
+	if err != nil {
+		return nil, reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	revised := *message
+	revised.ContentType = request.ContentType
+	revised.Content = request.Content
+	revised.Encryption = request.Encryption
+	revised.EncryptionPublicKey = request.EncryptionPublicKey
+	revised.Attachments = request.Attachments
+	revised.Headers = request.Headers
+
+	return m.revise(ctx, message, &revised)
+}
+
+func (m *RevisionManager) Retract(ctx context.Context, messageIdentifier string, outboxIdentifier string, actorAddress string, nodeIdentifier string) (*OutboxMessageResponse, error) {
+	message, err := m.getRevisableMessage(ctx, messageIdentifier, outboxIdentifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.deliveryManager.CancelPending(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.revise(ctx, message, retracted(message))
+}
+
+func (m *RevisionManager) ListOutboxRevisions(ctx context.Context, messageIdentifier string, outboxIdentifier string, actorAddress string, nodeIdentifier string) ([]*MessageRevision, error) {
+	message, err := m.getOutboxMessage(ctx, messageIdentifier, outboxIdentifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dataStore.FindByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message.Identifier, message.BoxType, message.BoxIdentifier, message.NodeIdentifier)
+}
+
+func (m *RevisionManager) ListInboxRevisions(ctx context.Context, messageIdentifier string, inboxIdentifier string, actorAddress string, nodeIdentifier string) ([]*MessageRevision, error) {
+	message, err := m.inboxManager.GetMessage(ctx, messageIdentifier, inboxIdentifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dataStore.FindByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message.Identifier, message.BoxType, message.BoxIdentifier, message.NodeIdentifier)
+}
+
+func (m *RevisionManager) Receive(ctx context.Context, request *DeliveryBatchRequest, senderAddress string, audience string) (*DeliveryBatchResponse, error) {
+	if request.Message.SenderAddress != senderAddress {
+		return nil, reject(http.StatusForbidden, "sender %s does not match authenticated actor", request.Message.SenderAddress)
+	}
+
+	err := m.inboxManager.authorizeRecipients(request.RecipientAddresses, audience)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Message.Revision <= 0 {
+		return nil, reject(http.StatusBadRequest, "revision must be greater than zero")
+	}
+
+	message, err := m.inboxManager.open(ctx, request.Message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DeliveryBatchResponse{}
+
+	for _, recipientAddress := range request.RecipientAddresses {
+		result := &DeliveryResult{RecipientAddress: recipientAddress, Status: DeliveryStatusDelivered}
+
+		err := m.apply(ctx, message, recipientAddress)
+
+		if err != nil {
+			result.Status = DeliveryStatusRejected
+			result.StatusCode = rejectionStatusCode(err, http.StatusInternalServerError)
+			result.Error = err.Error()
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+func (m *RevisionManager) revise(ctx context.Context, previous *Message, message *Message) (*OutboxMessageResponse, error) {
+	message.Revision = previous.Revision + 1
+	message.UpdatedAt = time.Now().UnixNano()
+	message.Signature = ""
+
+	sender, err := ParseActorAddress(message.SenderAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sender.Vertex == m.vertex {
+		message.Signature, err = m.envelopeManager.Sign(ctx, message)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = m.dataStore.Insert(ctx, previous.GetRevision())
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.messageDataStore.UpdateRevisionByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, message)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reject(http.StatusConflict, "message %s was revised concurrently", message.Identifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.blobManager.Replace(ctx, previous, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.propagate(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := m.deliveryManager.ListByMessage(ctx, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxMessageResponse{Message: message, Deliveries: deliveries}, nil
+}
+
+func (m *RevisionManager) propagate(ctx context.Context, message *Message) error {
+	deliveries, err := m.deliveryManager.ListByMessage(ctx, message)
+
+	if err != nil {
+		return err
+	}
+
+	var remoteRecipients []*InboxAddress
+	seen := make(map[string]bool)
+
+	for _, delivery := range deliveries {
+		if delivery.Revision != 0 || seen[delivery.RecipientAddress] {
+			continue
+		}
+
+		if delivery.Status != DeliveryStatusDelivered && delivery.Status != DeliveryStatusProcessing {
+			continue
+		}
+
+		seen[delivery.RecipientAddress] = true
+
+		recipient, err := ParseInboxAddress(delivery.RecipientAddress)
+
+		if err != nil {
+			return err
+		}
+
+		if recipient.Vertex != m.vertex {
+			remoteRecipients = append(remoteRecipients, recipient)
+			continue
+		}
+
+		applyErr := m.apply(ctx, message, recipient.String())
+
+		if applyErr != nil {
+			zap.L().Warn("local revision failed", zap.String("message", message.Identifier), zap.String("recipient", recipient.String()), zap.Error(applyErr))
+			_, err = m.deliveryManager.RecordRejected(ctx, message, recipient, applyErr)
+		} else {
+			_, err = m.deliveryManager.RecordDelivered(ctx, message, recipient)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(remoteRecipients) == 0 {
+		return nil
+	}
+
+	_, err = m.deliveryManager.Enqueue(ctx, message, remoteRecipients)
+
+	return err
+}
+
+func (m *RevisionManager) apply(ctx context.Context, message *Message, recipientAddress string) error {
+	recipient, err := ParseInboxAddress(recipientAddress)
+
+	if err != nil {
+		return reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	if recipient.Vertex != m.vertex {
+		return reject(http.StatusForbidden, "recipient %s is not hosted on this vertex", recipientAddress)
+	}
+
+	if !message.IsAddressedTo(recipient.String()) {
+		return reject(http.StatusForbidden, "message %s is not addressed to %s", message.Identifier, recipient.String())
+	}
+
+	copies, err := m.messageDataStore.FindByIdentifierAndBoxTypeAndActorAddressAndNodeIdentifier(ctx, message.Identifier, BoxTypeInbox, recipient.GetActorAddress(), recipient.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	applied := false
+
+	for _, current := range copies {
+		if current.RecipientAddress != recipient.String() {
+			continue
+		}
+
+		if current.SenderAddress != message.SenderAddress {
+			return reject(http.StatusForbidden, "revision of message %s is not signed by its original sender", message.Identifier)
+		}
+
+		applied = true
+
+		if message.Revision <= current.Revision {
+			continue
+		}
+
+		err = m.applyTo(ctx, current, message)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if !applied {
+		return reject(http.StatusTooEarly, "message %s has not been delivered to %s yet", message.Identifier, recipient.String())
+	}
+
+	return nil
+}
+
+func (m *RevisionManager) applyTo(ctx context.Context, current *Message, message *Message) error {
+	revised := *current
+	revised.ContentType = message.ContentType
+	revised.Content = message.Content
+	revised.Encryption = message.Encryption
+	revised.EncryptionPublicKey = message.EncryptionPublicKey
+	revised.Attachments = message.Attachments
+	revised.Headers = message.Headers
+	revised.Signature = message.Signature
+	revised.Revision = message.Revision
+	revised.RetractedAt = message.RetractedAt
+	revised.UpdatedAt = time.Now().UnixNano()
+
+	var err error
+
+	if revised.RetractedAt > 0 {
+		err = m.dataStore.DeleteByMessageIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, current.Identifier, current.BoxType, current.BoxIdentifier, current.NodeIdentifier)
+	} else {
+		_, err = m.dataStore.Insert(ctx, current.GetRevision())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = m.messageDataStore.UpdateRevisionByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, &revised)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = m.blobManager.Replace(ctx, current, &revised)
+
+	if err != nil {
+		return err
+	}
+
+	inbox, err := m.inboxDataStore.FindByIdentifierAndActorAddressAndNodeIdentifier(ctx, current.BoxIdentifier, current.ActorAddress, current.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	if revised.RetractedAt > 0 {
+		_, err = m.eventManager.Retract(ctx, inbox, &revised)
+	} else {
+		_, err = m.eventManager.Record(ctx, inbox, InboxEventTypeRevision, &revised)
+	}
+
+	if err != nil {
+		zap.L().Error("error recording inbox event", zap.String("inbox", inbox.Identifier), zap.String("message", revised.Identifier), zap.Error(err))
+	}
+
+	return nil
+}
+
+func (m *RevisionManager) getRevisableMessage(ctx context.Context, messageIdentifier string, outboxIdentifier string, actorAddress string, nodeIdentifier string) (*Message, error) {
+	message, err := m.getOutboxMessage(ctx, messageIdentifier, outboxIdentifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if message.RetractedAt > 0 {
+		return nil, reject(http.StatusConflict, "message %s has been retracted", messageIdentifier)
+	}
+
+	return message, nil
+}
+
+func (m *RevisionManager) getOutboxMessage(ctx context.Context, messageIdentifier string, outboxIdentifier string, actorAddress string, nodeIdentifier string) (*Message, error) {
+	outbox, err := m.outboxManager.Get(ctx, outboxIdentifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, reject(http.StatusNotFound, "%s", err.Error())
+	}
+
+	message, err := m.messageDataStore.FindByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, messageIdentifier, BoxTypeOutbox, outbox.Identifier, outbox.NodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reject(http.StatusNotFound, "message %s not found", messageIdentifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func retracted(message *Message) *Message {
+	revised := *message
+	revised.ContentType = ""
+	revised.Content = ""
+	revised.Encryption = ""
+	revised.EncryptionPublicKey = ""
+	revised.Attachments = nil
+	revised.Headers = nil
+	revised.RetractedAt = time.Now().UnixNano()
+	return &revised
+}
@@ -0,0 +1,57 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type SearchHandler struct {
+	router        *gin.Engine
+	authenticator *actor.Authenticator
+	manager       *SearchManager
+}
+
+func NewSearchHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *SearchManager) *SearchHandler {
+	return &SearchHandler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *SearchHandler) Register() {
+
+	h.router.GET("/api/v1/messaging/search", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		var request MessageSearchRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		messages, err := h.manager.Search(ctx, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, pagination)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Paginated(c, http.StatusOK, pagination, messages, func(message *Message) *api.Cursor {
+			return &api.Cursor{CreatedAt: message.CreatedAt, Identifier: message.Identifier}
+		})
+	})
+}
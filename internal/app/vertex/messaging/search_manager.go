@@ -0,0 +1,28 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"net/http"
+	"strings"
+)
+
+type SearchManager struct {
+	messageDataStore *MessageDataStore
+}
+
+func NewSearchManager(messageDataStore *MessageDataStore) *SearchManager {
+	return &SearchManager{messageDataStore: messageDataStore}
+}
+
+func (m *SearchManager) Search(ctx context.Context, request *MessageSearchRequest, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Message, error) {
+	if strings.TrimSpace(request.Query) == "" {
+		return nil, reject(http.StatusBadRequest, "search query is required")
+	}
+
+	if request.From > 0 && request.To > 0 && request.From > request.To {
+		return nil, reject(http.StatusBadRequest, "invalid date range")
+	}
+
+	return m.messageDataStore.Search(ctx, request, actorAddress, nodeIdentifier, pagination)
+}
@@ -0,0 +1,122 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"slices"
+	"testing"
+)
+
+func TestSearchManager(t *testing.T) {
+	ctx := context.Background()
+	database := newTestDatabase(t)
+	messageDataStore := NewMessageDataStore(database)
+	manager := NewSearchManager(messageDataStore)
+
+	owner := "local.example/node/owner"
+
+	insert := func(identifier string, content string, encryption string) *Message {
+		t.Helper()
+
+		message, err := messageDataStore.Insert(ctx, &Message{
+			Identifier:       identifier,
+			BoxType:          BoxTypeInbox,
+			BoxIdentifier:    "main",
+			NodeIdentifier:   "node",
+			ActorAddress:     owner,
+			SenderAddress:    "remote.example/node/alice",
+			RecipientAddress: owner + "/main",
+			ContentType:      "text/plain",
+			Content:          content,
+			Encryption:       encryption,
+			State:            MessageStateUnread,
+			CreatedAt:        int64(len(identifier)),
+			UpdatedAt:        int64(len(identifier)),
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return message
+	}
+
+	search := func(query string) []string {
+		t.Helper()
+
+		messages, err := manager.Search(ctx, &MessageSearchRequest{Query: query}, owner, "node", &api.Pagination{Size: 10})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var identifiers []string
+
+		for _, message := range messages {
+			identifiers = append(identifiers, message.Identifier)
+		}
+
+		slices.Sort(identifiers)
+
+		return identifiers
+	}
+
+	expect := func(query string, expected ...string) {
+		t.Helper()
+
+		if got := search(query); !slices.Equal(got, expected) {
+			t.Fatalf("search %q: expected %v, got %v", query, expected, got)
+		}
+	}
+
+	insert("first", "the quick brown fox", "")
+	insert("second", "a lazy café dog", "")
+	edited := insert("third", "original text", "")
+	insert("sealed", "quick secret", "x25519")
+
+	expect("quick", "first")
+	expect("cafe", "second")
+	expect("alice", "first", "sealed", "second", "third")
+	expect("secret")
+
+	edited.Content = "rewritten quick text"
+	edited.Revision = 1
+
+	err := messageDataStore.UpdateRevisionByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, edited)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect("quick", "first", "third")
+	expect("original")
+
+	err = messageDataStore.DeleteByIdentifierAndBoxTypeAndBoxIdentifierAndNodeIdentifier(ctx, "first", BoxTypeInbox, "main", "node")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect("quick", "third")
+	expect("fox")
+
+	_, err = database.Exec("VACUUM")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insert("fourth", "quick again", "")
+
+	expect("quick", "fourth", "third")
+
+	messages, err := manager.Search(ctx, &MessageSearchRequest{Query: "quick"}, "other.example/node/mallory", "node", &api.Pagination{Size: 10})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 0 {
+		t.Fatalf("expected no results for another actor, got %d", len(messages))
+	}
+}
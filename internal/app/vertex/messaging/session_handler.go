@@ -0,0 +1,76 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type SessionHandler struct {
+	router         *gin.Engine
+	authenticator  *actor.Authenticator
+	manager        *SessionManager
+	vertex         string
+	allowedOrigins []string
+}
+
+func NewSessionHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *SessionManager, vertex string, allowedOrigins []string) *SessionHandler {
+	normalizedOrigins := make([]string, 0, len(allowedOrigins))
+
+	for _, origin := range allowedOrigins {
+		normalizedOrigins = append(normalizedOrigins, strings.ToLower(strings.TrimSuffix(origin, "/")))
+	}
+
+	return &SessionHandler{router: router, authenticator: authenticator, manager: manager, vertex: vertex, allowedOrigins: normalizedOrigins}
+}
+
+func (h *SessionHandler) Register() {
+
+	h.router.GET("/api/v1/messaging/sessions", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		websocket.Server{
+			Handshake: func(config *websocket.Config, request *http.Request) error {
+				return h.checkOrigin(request)
+			},
+			Handler: func(conn *websocket.Conn) {
+				h.manager.Serve(c.Request.Context(), conn, authenticatedActor)
+			},
+		}.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+func (h *SessionHandler) checkOrigin(request *http.Request) error {
+	origin := request.Header.Get("Origin")
+
+	if origin == "" {
+		return nil
+	}
+
+	target, err := url.Parse(origin)
+
+	if err != nil || target.Host == "" {
+		return fmt.Errorf("invalid origin %s", origin)
+	}
+
+	if strings.EqualFold(target.Host, h.vertex) || slices.Contains(h.allowedOrigins, strings.ToLower(origin)) {
+		return nil
+	}
+
+	return fmt.Errorf("origin %s is not allowed", origin)
+}
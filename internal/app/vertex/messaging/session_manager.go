@@ -0,0 +1,312 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"golang.org/x/net/websocket"
+	"sync"
+	"time"
+)
+
+const (
+	sessionOutgoingBufferSize = 256
+	sessionHeartbeatInterval  = 30 * time.Second
+	sessionReadTimeout        = 3 * sessionHeartbeatInterval
+	sessionWriteTimeout       = 10 * time.Second
+	sessionRequestTimeout     = 5 * time.Second
+)
+
+type SessionManager struct {
+	inboxManager  *InboxManager
+	outboxManager *OutboxManager
+	mutex         sync.Mutex
+	sessions      map[*Session]struct{}
+	closed        bool
+}
+
+func NewSessionManager(inboxManager *InboxManager, outboxManager *OutboxManager) *SessionManager {
+	return &SessionManager{
+		inboxManager:  inboxManager,
+		outboxManager: outboxManager,
+		sessions:      make(map[*Session]struct{}),
+	}
+}
+
+func (m *SessionManager) Serve(ctx context.Context, conn *websocket.Conn, authenticatedActor *actor.AuthenticatedActor) {
+	session := &Session{
+		conn:               conn,
+		authenticatedActor: authenticatedActor,
+		inboxManager:       m.inboxManager,
+		outboxManager:      m.outboxManager,
+		outgoing:           make(chan *SessionResponse, sessionOutgoingBufferSize),
+		subscriptions:      make(map[string]*InboxSubscription),
+		done:               make(chan struct{}),
+	}
+
+	if !m.add(session) {
+		session.Close()
+		return
+	}
+
+	defer m.remove(session)
+
+	session.run(ctx)
+}
+
+func (m *SessionManager) Run(ctx context.Context) {
+	<-ctx.Done()
+
+	m.mutex.Lock()
+	m.closed = true
+	sessions := make([]*Session, 0, len(m.sessions))
+	for session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mutex.Unlock()
+
+	for _, session := range sessions {
+		session.Close()
+	}
+}
+
+func (m *SessionManager) add(session *Session) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return false
+	}
+
+	m.sessions[session] = struct{}{}
+	return true
+}
+
+func (m *SessionManager) remove(session *Session) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.sessions, session)
+}
+
+type Session struct {
+	conn               *websocket.Conn
+	authenticatedActor *actor.AuthenticatedActor
+	inboxManager       *InboxManager
+	outboxManager      *OutboxManager
+	outgoing           chan *SessionResponse
+	mutex              sync.Mutex
+	subscriptions      map[string]*InboxSubscription
+	done               chan struct{}
+	closeOnce          sync.Once
+}
+
+func (s *Session) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.conn.Close()
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for inboxIdentifier, subscription := range s.subscriptions {
+			subscription.Close()
+			delete(s.subscriptions, inboxIdentifier)
+		}
+	})
+}
+
+func (s *Session) run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer s.Close()
+
+	go s.write()
+
+	for {
+		err := s.conn.SetReadDeadline(time.Now().Add(sessionReadTimeout))
+
+		if err != nil {
+			return
+		}
+
+		var request SessionRequest
+		err = websocket.JSON.Receive(s.conn, &request)
+
+		if err != nil {
+			return
+		}
+
+		s.handle(ctx, &request)
+	}
+}
+
+func (s *Session) write() {
+	heartbeat := time.NewTicker(sessionHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var response *SessionResponse
+
+		select {
+		case <-s.done:
+			return
+		case response = <-s.outgoing:
+		case <-heartbeat.C:
+			response = &SessionResponse{Type: SessionFrameTypePing}
+		}
+
+		err := s.conn.SetWriteDeadline(time.Now().Add(sessionWriteTimeout))
+
+		if err == nil {
+			err = websocket.JSON.Send(s.conn, response)
+		}
+
+		if err != nil {
+			s.Close()
+			return
+		}
+	}
+}
+
+func (s *Session) send(response *SessionResponse) bool {
+	select {
+	case s.outgoing <- response:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *Session) handle(ctx context.Context, request *SessionRequest) {
+	switch request.Type {
+	case SessionFrameTypeSubscribe:
+		s.subscribe(ctx, request)
+	case SessionFrameTypeUnsubscribe:
+		s.unsubscribe(request)
+	case SessionFrameTypeSend:
+		s.sendMessage(ctx, request)
+	case SessionFrameTypePing:
+		s.send(&SessionResponse{Type: SessionFrameTypePong, RequestIdentifier: request.RequestIdentifier})
+	case SessionFrameTypePong:
+	default:
+		s.fail(request, fmt.Errorf("invalid frame type %s", request.Type))
+	}
+}
+
+func (s *Session) subscribe(ctx context.Context, request *SessionRequest) {
+	requestCtx, cancel := context.WithTimeout(ctx, sessionRequestTimeout)
+	defer cancel()
+
+	inbox, err := s.inboxManager.Get(requestCtx, request.InboxIdentifier, s.authenticatedActor.Address, s.authenticatedActor.TargetNodeIdentifier)
+
+	if err != nil {
+		s.fail(request, err)
+		return
+	}
+
+	s.mutex.Lock()
+
+	select {
+	case <-s.done:
+		s.mutex.Unlock()
+		return
+	default:
+	}
+
+	if _, ok := s.subscriptions[inbox.Identifier]; ok {
+		s.mutex.Unlock()
+		s.fail(request, fmt.Errorf("inbox %s is already subscribed", inbox.Identifier))
+		return
+	}
+
+	subscription := s.inboxManager.Subscribe(inbox)
+	s.subscriptions[inbox.Identifier] = subscription
+	s.mutex.Unlock()
+
+	s.send(&SessionResponse{Type: SessionFrameTypeAck, RequestIdentifier: request.RequestIdentifier, InboxIdentifier: inbox.Identifier})
+
+	go s.forward(ctx, inbox, subscription, request.LastEventIdentifier)
+}
+
+func (s *Session) unsubscribe(request *SessionRequest) {
+	s.mutex.Lock()
+	subscription, ok := s.subscriptions[request.InboxIdentifier]
+	delete(s.subscriptions, request.InboxIdentifier)
+	s.mutex.Unlock()
+
+	if !ok {
+		s.fail(request, fmt.Errorf("inbox %s is not subscribed", request.InboxIdentifier))
+		return
+	}
+
+	subscription.Close()
+
+	s.send(&SessionResponse{Type: SessionFrameTypeAck, RequestIdentifier: request.RequestIdentifier, InboxIdentifier: request.InboxIdentifier})
+}
+
+func (s *Session) forward(ctx context.Context, inbox *Inbox, subscription *InboxSubscription, sequence int64) {
+	for {
+		events, err := s.inboxManager.ListEvents(ctx, inbox, sequence, streamReplayBatchSize)
+
+		if err != nil {
+			s.Close()
+			return
+		}
+
+		for _, event := range events {
+			if !s.send(&SessionResponse{Type: SessionFrameTypeEvent, InboxIdentifier: inbox.Identifier, Event: event}) {
+				return
+			}
+
+			sequence = event.Sequence
+		}
+
+		if len(events) < streamReplayBatchSize {
+			break
+		}
+	}
+
+	for event := range subscription.Events() {
+		if event.Sequence <= sequence {
+			continue
+		}
+
+		if !s.send(&SessionResponse{Type: SessionFrameTypeEvent, InboxIdentifier: inbox.Identifier, Event: event}) {
+			return
+		}
+
+		sequence = event.Sequence
+	}
+
+	s.mutex.Lock()
+	current, ok := s.subscriptions[inbox.Identifier]
+	s.mutex.Unlock()
+
+	if ok && current == subscription {
+		s.Close()
+	}
+}
+
+func (s *Session) sendMessage(ctx context.Context, request *SessionRequest) {
+	if request.Message == nil || (request.Message.RecipientAddress == "" && len(request.Message.RecipientAddresses) == 0) || request.Message.ContentType == "" || request.Message.Content == "" {
+		s.fail(request, fmt.Errorf("recipient_address or recipient_addresses, content_type and content are required"))
+		return
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, sessionRequestTimeout)
+	defer cancel()
+
+	message, err := s.outboxManager.Send(requestCtx, request.OutboxIdentifier, request.Message, s.authenticatedActor.Address, s.authenticatedActor.TargetNodeIdentifier)
+
+	if err != nil {
+		s.fail(request, err)
+		return
+	}
+
+	s.send(&SessionResponse{Type: SessionFrameTypeAck, RequestIdentifier: request.RequestIdentifier, Data: message})
+}
+
+func (s *Session) fail(request *SessionRequest, err error) {
+	s.send(&SessionResponse{Type: SessionFrameTypeError, RequestIdentifier: request.RequestIdentifier, Message: err.Error()})
+}
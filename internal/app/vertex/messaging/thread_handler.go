@@ -0,0 +1,53 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type ThreadHandler struct {
+	router        *gin.Engine
+	authenticator *actor.Authenticator
+	manager       *ThreadManager
+}
+
+func NewThreadHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *ThreadManager) *ThreadHandler {
+	return &ThreadHandler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *ThreadHandler) Register() {
+
+	h.router.GET("/api/v1/messaging/threads/:threadIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		threadIdentifier := c.Param("threadIdentifier")
+
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		messages, err := h.manager.Get(ctx, threadIdentifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, pagination)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Paginated(c, http.StatusOK, pagination, messages, func(message *Message) *api.Cursor {
+			return &api.Cursor{CreatedAt: message.CreatedAt, Identifier: message.Identifier}
+		})
+	})
+}
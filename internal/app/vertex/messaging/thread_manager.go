@@ -0,0 +1,49 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+)
+
+type ThreadManager struct {
+	messageDataStore *MessageDataStore
+}
+
+func NewThreadManager(messageDataStore *MessageDataStore) *ThreadManager {
+	return &ThreadManager{messageDataStore: messageDataStore}
+}
+
+func (m *ThreadManager) Resolve(ctx context.Context, inReplyTo string, actorAddress string, nodeIdentifier string) (string, error) {
+	parent, err := m.messageDataStore.FindFirstByIdentifierAndActorAddressAndNodeIdentifier(ctx, inReplyTo, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("message %s not found", inReplyTo)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if parent.ThreadIdentifier == "" {
+		return parent.Identifier, nil
+	}
+
+	return parent.ThreadIdentifier, nil
+}
+
+func (m *ThreadManager) Get(ctx context.Context, threadIdentifier string, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Message, error) {
+	thread, err := m.messageDataStore.FindByThreadIdentifierAndActorAddressAndNodeIdentifier(ctx, threadIdentifier, actorAddress, nodeIdentifier, pagination)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(thread) == 0 && pagination.Offset() == 0 && pagination.Cursor == nil {
+		return nil, fmt.Errorf("thread %s not found", threadIdentifier)
+	}
+
+	return thread, nil
+}
@@ -0,0 +1,142 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	webhookClientTimeout = 10 * time.Second
+	webhookDialTimeout   = 5 * time.Second
+)
+
+type WebhookAddressPolicy struct {
+	hosts    []string
+	prefixes []netip.Prefix
+}
+
+func NewWebhookAddressPolicy(allowed []string) *WebhookAddressPolicy {
+	policy := &WebhookAddressPolicy{}
+
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+
+		if entry == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			policy.prefixes = append(policy.prefixes, prefix.Masked())
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			policy.prefixes = append(policy.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		policy.hosts = append(policy.hosts, entry)
+	}
+
+	return policy
+}
+
+func (p *WebhookAddressPolicy) Client() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   webhookDialTimeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			return p.checkAddress(address)
+		},
+	}
+
+	unrestricted := &net.Dialer{
+		Timeout:   webhookDialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+
+		if err == nil && p.allowsHost(host) {
+			return unrestricted.DialContext(ctx, network, address)
+		}
+
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{
+		Timeout:   webhookClientTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (p *WebhookAddressPolicy) Validate(host string) error {
+	if p.allowsHost(host) {
+		return nil
+	}
+
+	addr, err := netip.ParseAddr(host)
+
+	if err != nil {
+		if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+			return fmt.Errorf("webhook host %s is not allowed", host)
+		}
+
+		return nil
+	}
+
+	if !p.allowsAddr(addr) {
+		return fmt.Errorf("webhook address %s is not allowed", addr)
+	}
+
+	return nil
+}
+
+func (p *WebhookAddressPolicy) checkAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+
+	if err != nil {
+		return err
+	}
+
+	if !p.allowsAddr(addr) {
+		return fmt.Errorf("webhook address %s is not allowed", addr)
+	}
+
+	return nil
+}
+
+func (p *WebhookAddressPolicy) allowsHost(host string) bool {
+	return slices.Contains(p.hosts, strings.ToLower(host))
+}
+
+func (p *WebhookAddressPolicy) allowsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
@@ -0,0 +1,88 @@
+package messaging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestWebhookAddressPolicyValidate(t *testing.T) {
+	policy := NewWebhookAddressPolicy([]string{"hooks.internal", "10.1.0.0/16", "192.168.1.5"})
+
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{"example.com", true},
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"hooks.internal", true},
+		{"HOOKS.internal", true},
+		{"10.1.2.3", true},
+		{"192.168.1.5", true},
+		{"localhost", false},
+		{"api.localhost", false},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.2.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.6", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		err := policy.Validate(test.host)
+
+		if (err == nil) != test.allowed {
+			t.Errorf("Validate(%q) = %v, expected allowed %v", test.host, err, test.allowed)
+		}
+	}
+}
+
+func TestWebhookAddressPolicyClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	target, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewWebhookAddressPolicy(nil).Client().Get(server.URL)
+
+	if err == nil {
+		t.Fatal("expected a loopback webhook target to be refused")
+	}
+
+	_, err = NewWebhookAddressPolicy(nil).Client().Get("http://localhost:" + target.Port())
+
+	if err == nil {
+		t.Fatal("expected a hostname resolving to loopback to be refused")
+	}
+
+	for _, allowed := range []string{"127.0.0.1", "127.0.0.0/8"} {
+		response, err := NewWebhookAddressPolicy([]string{allowed}).Client().Get(server.URL)
+
+		if err != nil {
+			t.Fatalf("expected %s to be allowed: %v", allowed, err)
+		}
+
+		_ = response.Body.Close()
+	}
+
+	response, err := NewWebhookAddressPolicy([]string{"localhost"}).Client().Get("http://localhost:" + target.Port())
+
+	if err != nil {
+		t.Fatalf("expected an allowlisted host to be allowed: %v", err)
+	}
+
+	_ = response.Body.Close()
+}
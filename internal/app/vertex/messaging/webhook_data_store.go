@@ -0,0 +1,421 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"go.uber.org/zap"
+)
+
+type WebhookDataStore struct {
+	db *sql.DB
+}
+
+func NewWebhookDataStore(db *sql.DB) *WebhookDataStore {
+	return &WebhookDataStore{db: db}
+}
+
+func (d *WebhookDataStore) Insert(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	events, err := encodeJSON(webhook.Events, len(webhook.Events))
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = d.db.ExecContext(ctx,
+		"INSERT INTO webhooks (identifier, actor_address, node_identifier, inbox_identifier, url, events, secret, enabled, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		webhook.Identifier,
+		webhook.ActorAddress,
+		webhook.NodeIdentifier,
+		webhook.InboxIdentifier,
+		webhook.URL,
+		events,
+		webhook.Secret,
+		webhook.Enabled,
+		webhook.CreatedAt,
+		webhook.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (d *WebhookDataStore) FindByActorAddressAndNodeIdentifier(ctx context.Context, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Webhook, error) {
+	createdAt, identifier := pagination.Bounds(false)
+
+	return d.findAll(ctx,
+		"SELECT identifier, actor_address, node_identifier, inbox_identifier, url, events, secret, enabled, created_at, updated_at FROM webhooks WHERE actor_address = ? AND node_identifier = ? AND (created_at, identifier) > (?, ?) ORDER BY created_at, identifier LIMIT ? OFFSET ?",
+		actorAddress, nodeIdentifier, createdAt, identifier, pagination.Size, pagination.Offset())
+}
+
+func (d *WebhookDataStore) FindByActorAddressAndNodeIdentifierAndEnabled(ctx context.Context, actorAddress string, nodeIdentifier string, enabled bool) ([]*Webhook, error) {
+	return d.findAll(ctx,
+		"SELECT identifier, actor_address, node_identifier, inbox_identifier, url, events, secret, enabled, created_at, updated_at FROM webhooks WHERE actor_address = ? AND node_identifier = ? AND enabled = ? ORDER BY created_at, identifier",
+		actorAddress, nodeIdentifier, enabled)
+}
+
+func (d *WebhookDataStore) findAll(ctx context.Context, query string, args ...interface{}) ([]*Webhook, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var webhooks []*Webhook
+
+	for rows.Next() {
+		var webhook Webhook
+		var events string
+
+		err = rows.Scan(
+			&webhook.Identifier,
+			&webhook.ActorAddress,
+			&webhook.NodeIdentifier,
+			&webhook.InboxIdentifier,
+			&webhook.URL,
+			&events,
+			&webhook.Secret,
+			&webhook.Enabled,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeJSON(events, &webhook.Events)
+
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, &webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (d *WebhookDataStore) FindByIdentifier(ctx context.Context, identifier string) (*Webhook, error) {
+	return d.find(ctx,
+		"SELECT identifier, actor_address, node_identifier, inbox_identifier, url, events, secret, enabled, created_at, updated_at FROM webhooks WHERE identifier = ?",
+		identifier)
+}
+
+func (d *WebhookDataStore) FindByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*Webhook, error) {
+	return d.find(ctx,
+		"SELECT identifier, actor_address, node_identifier, inbox_identifier, url, events, secret, enabled, created_at, updated_at FROM webhooks WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		identifier, actorAddress, nodeIdentifier)
+}
+
+func (d *WebhookDataStore) find(ctx context.Context, query string, args ...interface{}) (*Webhook, error) {
+	var webhook Webhook
+	var events string
+
+	err := d.db.QueryRowContext(ctx, query, args...).
+		Scan(
+			&webhook.Identifier,
+			&webhook.ActorAddress,
+			&webhook.NodeIdentifier,
+			&webhook.InboxIdentifier,
+			&webhook.URL,
+			&events,
+			&webhook.Secret,
+			&webhook.Enabled,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = decodeJSON(events, &webhook.Events)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (d *WebhookDataStore) Update(ctx context.Context, webhook *Webhook) error {
+	events, err := encodeJSON(webhook.Events, len(webhook.Events))
+
+	if err != nil {
+		return err
+	}
+
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE webhooks SET inbox_identifier = ?, url = ?, events = ?, enabled = ?, updated_at = ? WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		webhook.InboxIdentifier,
+		webhook.URL,
+		events,
+		webhook.Enabled,
+		webhook.UpdatedAt,
+		webhook.Identifier,
+		webhook.ActorAddress,
+		webhook.NodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *WebhookDataStore) UpdateSecretByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, secret string, identifier string, actorAddress string, nodeIdentifier string, updatedAt int64) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE webhooks SET secret = ?, updated_at = ? WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		secret, updatedAt, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *WebhookDataStore) DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
+	result, err := d.db.ExecContext(ctx,
+		"DELETE FROM webhooks WHERE identifier = ? AND actor_address = ? AND node_identifier = ?",
+		identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *WebhookDataStore) DeleteByInboxIdentifierAndNodeIdentifier(ctx context.Context, inboxIdentifier string, nodeIdentifier string) error {
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM webhook_deliveries WHERE webhook_identifier IN (SELECT identifier FROM webhooks WHERE inbox_identifier = ? AND node_identifier = ?)",
+		inboxIdentifier, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.ExecContext(ctx,
+		"DELETE FROM webhooks WHERE inbox_identifier = ? AND node_identifier = ?",
+		inboxIdentifier, nodeIdentifier)
+
+	return err
+}
+
+func (d *WebhookDataStore) InsertDelivery(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error) {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO webhook_deliveries (identifier, webhook_identifier, event, payload, status, attempts, status_code, last_error, next_attempt_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		delivery.Identifier,
+		delivery.WebhookIdentifier,
+		delivery.Event,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.StatusCode,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+		delivery.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (d *WebhookDataStore) FindDueDeliveries(ctx context.Context, now int64, size int64) ([]*WebhookDelivery, error) {
+	return d.findAllDeliveries(ctx,
+		"SELECT identifier, webhook_identifier, event, payload, status, attempts, status_code, last_error, next_attempt_at, created_at, updated_at FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?",
+		WebhookDeliveryStatusPending, now, size)
+}
+
+func (d *WebhookDataStore) FindDeliveriesByWebhookIdentifier(ctx context.Context, webhookIdentifier string, pagination *api.Pagination) ([]*WebhookDelivery, error) {
+	createdAt, identifier := pagination.Bounds(true)
+
+	return d.findAllDeliveries(ctx,
+		"SELECT identifier, webhook_identifier, event, payload, status, attempts, status_code, last_error, next_attempt_at, created_at, updated_at FROM webhook_deliveries WHERE webhook_identifier = ? AND (created_at, identifier) < (?, ?) ORDER BY created_at DESC, identifier DESC LIMIT ? OFFSET ?",
+		webhookIdentifier, createdAt, identifier, pagination.Size, pagination.Offset())
+}
+
+func (d *WebhookDataStore) findAllDeliveries(ctx context.Context, query string, args ...interface{}) ([]*WebhookDelivery, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var deliveries []*WebhookDelivery
+
+	for rows.Next() {
+		var delivery WebhookDelivery
+		err = rows.Scan(
+			&delivery.Identifier,
+			&delivery.WebhookIdentifier,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.StatusCode,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (d *WebhookDataStore) FindDeliveryByIdentifierAndWebhookIdentifier(ctx context.Context, identifier string, webhookIdentifier string) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT identifier, webhook_identifier, event, payload, status, attempts, status_code, last_error, next_attempt_at, created_at, updated_at FROM webhook_deliveries WHERE identifier = ? AND webhook_identifier = ?",
+		identifier, webhookIdentifier).
+		Scan(
+			&delivery.Identifier,
+			&delivery.WebhookIdentifier,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.StatusCode,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (d *WebhookDataStore) UpdateDeliveryStatusByIdentifierAndStatus(ctx context.Context, newStatus string, identifier string, status string, updatedAt int64) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = ?, updated_at = ? WHERE identifier = ? AND status = ?",
+		newStatus, updatedAt, identifier, status)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *WebhookDataStore) UpdateDeliveryStatusByStatus(ctx context.Context, newStatus string, status string, updatedAt int64) error {
+	_, err := d.db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = ?, updated_at = ? WHERE status = ?",
+		newStatus, updatedAt, status)
+
+	return err
+}
+
+func (d *WebhookDataStore) UpdateDeliveryStatusAndAttemptsAndStatusCodeAndLastErrorAndNextAttemptAtByIdentifier(ctx context.Context, status string, attempts int64, statusCode int, lastError string, nextAttemptAt int64, identifier string, updatedAt int64) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = ?, attempts = ?, status_code = ?, last_error = ?, next_attempt_at = ?, updated_at = ? WHERE identifier = ?",
+		status, attempts, statusCode, lastError, nextAttemptAt, updatedAt, identifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *WebhookDataStore) DeleteDeliveriesByWebhookIdentifier(ctx context.Context, webhookIdentifier string) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_identifier = ?", webhookIdentifier)
+	return err
+}
+
+func (d *WebhookDataStore) DeleteDeliveriesByStatusInAndCreatedAtBefore(ctx context.Context, statuses []string, createdAt int64) error {
+	args := make([]interface{}, 0, len(statuses)+1)
+
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+
+	args = append(args, createdAt)
+
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM webhook_deliveries WHERE status IN ("+placeholders(len(statuses))+") AND created_at < ?",
+		args...)
+
+	return err
+}
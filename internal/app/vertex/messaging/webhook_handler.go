@@ -0,0 +1,246 @@
+package messaging
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/actor"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type WebhookHandler struct {
+	router        *gin.Engine
+	authenticator *actor.Authenticator
+	manager       *WebhookManager
+}
+
+func NewWebhookHandler(router *gin.Engine, authenticator *actor.Authenticator, manager *WebhookManager) *WebhookHandler {
+	return &WebhookHandler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *WebhookHandler) Register() {
+
+	h.router.POST("/api/v1/messaging/webhooks", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		var request WebhookRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		webhook, err := h.manager.Create(ctx, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, webhook)
+	})
+
+	h.router.GET("/api/v1/messaging/webhooks", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		webhooks, err := h.manager.List(ctx, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, pagination)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Paginated(c, http.StatusOK, pagination, webhooks, func(webhook *Webhook) *api.Cursor {
+			return &api.Cursor{CreatedAt: webhook.CreatedAt, Identifier: webhook.Identifier}
+		})
+	})
+
+	h.router.GET("/api/v1/messaging/webhooks/:webhookIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("webhookIdentifier")
+
+		webhook, err := h.manager.Get(ctx, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, webhook)
+	})
+
+	h.router.PUT("/api/v1/messaging/webhooks/:webhookIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("webhookIdentifier")
+		var request WebhookRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.manager.Update(ctx, identifier, &request, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "webhook updated successfully")
+	})
+
+	h.router.DELETE("/api/v1/messaging/webhooks/:webhookIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("webhookIdentifier")
+
+		err = h.manager.Delete(ctx, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "webhook deleted successfully")
+	})
+
+	h.router.POST("/api/v1/messaging/webhooks/:webhookIdentifier/secret", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("webhookIdentifier")
+
+		webhook, err := h.manager.RotateSecret(ctx, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, webhook)
+	})
+
+	h.router.GET("/api/v1/messaging/webhooks/:webhookIdentifier/deliveries", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("webhookIdentifier")
+
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		deliveries, err := h.manager.ListDeliveries(ctx, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier, pagination)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		api.Paginated(c, http.StatusOK, pagination, deliveries, func(delivery *WebhookDelivery) *api.Cursor {
+			return &api.Cursor{CreatedAt: delivery.CreatedAt, Identifier: delivery.Identifier}
+		})
+	})
+
+	h.router.GET("/api/v1/messaging/webhooks/:webhookIdentifier/deliveries/:deliveryIdentifier", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("webhookIdentifier")
+		deliveryIdentifier := c.Param("deliveryIdentifier")
+
+		delivery, err := h.manager.GetDelivery(ctx, deliveryIdentifier, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, delivery)
+	})
+
+	h.router.POST("/api/v1/messaging/webhooks/:webhookIdentifier/deliveries/:deliveryIdentifier/redeliver", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedActor, err := h.authenticator.ValidateContext(ctx, c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		identifier := c.Param("webhookIdentifier")
+		deliveryIdentifier := c.Param("deliveryIdentifier")
+
+		delivery, err := h.manager.Redeliver(ctx, deliveryIdentifier, identifier, authenticatedActor.Address, authenticatedActor.TargetNodeIdentifier)
+
+		if err != nil {
+			api.Error(c, rejectionStatusCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, delivery)
+	})
+}
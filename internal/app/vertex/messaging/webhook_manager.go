@@ -0,0 +1,533 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/evernetproto/evernet/internal/pkg/ids"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const (
+	webhookBatchSize       = 50
+	webhookAttemptTimeout  = 10 * time.Second
+	webhookLogRetention    = 7 * 24 * time.Hour
+	webhookPurgeInterval   = time.Hour
+	webhookMaxErrorLength  = 512
+	webhookMaxResponseRead = 4096
+)
+
+type WebhookManager struct {
+	maxAttempts    int64
+	dataStore      *WebhookDataStore
+	inboxDataStore *InboxDataStore
+	addressPolicy  *WebhookAddressPolicy
+	client         *http.Client
+	notifications  chan struct{}
+}
+
+func NewWebhookManager(maxAttempts int64, dataStore *WebhookDataStore, inboxDataStore *InboxDataStore, addressPolicy *WebhookAddressPolicy) *WebhookManager {
+	return &WebhookManager{
+		maxAttempts:    maxAttempts,
+		dataStore:      dataStore,
+		inboxDataStore: inboxDataStore,
+		addressPolicy:  addressPolicy,
+		client:         addressPolicy.Client(),
+		notifications:  make(chan struct{}, 1),
+	}
+}
+
+func (m *WebhookManager) Create(ctx context.Context, request *WebhookRequest, actorAddress string, nodeIdentifier string) (*WebhookSecretResponse, error) {
+	err := m.validate(ctx, request, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	identifier, err := ids.Generate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &Webhook{
+		Identifier:      identifier,
+		ActorAddress:    actorAddress,
+		NodeIdentifier:  nodeIdentifier,
+		InboxIdentifier: request.InboxIdentifier,
+		URL:             request.URL,
+		Events:          uniqueEvents(request.Events),
+		Secret:          secret,
+		Enabled:         request.Enabled == nil || *request.Enabled,
+		CreatedAt:       time.Now().UnixNano(),
+		UpdatedAt:       time.Now().UnixNano(),
+	}
+
+	webhook, err = m.dataStore.Insert(ctx, webhook)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookSecretResponse{Webhook: webhook, Secret: webhook.Secret}, nil
+}
+
+func (m *WebhookManager) List(ctx context.Context, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*Webhook, error) {
+	return m.dataStore.FindByActorAddressAndNodeIdentifier(ctx, actorAddress, nodeIdentifier, pagination)
+}
+
+func (m *WebhookManager) Get(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*Webhook, error) {
+	webhook, err := m.dataStore.FindByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reject(http.StatusNotFound, "webhook %s not found", identifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (m *WebhookManager) Update(ctx context.Context, identifier string, request *WebhookRequest, actorAddress string, nodeIdentifier string) error {
+	webhook, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.validate(ctx, request, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return err
+	}
+
+	if request.Enabled != nil {
+		webhook.Enabled = *request.Enabled
+	}
+
+	webhook.InboxIdentifier = request.InboxIdentifier
+	webhook.URL = request.URL
+	webhook.Events = uniqueEvents(request.Events)
+	webhook.UpdatedAt = time.Now().UnixNano()
+
+	err = m.dataStore.Update(ctx, webhook)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "webhook %s not found", identifier)
+	}
+
+	return err
+}
+
+func (m *WebhookManager) RotateSecret(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) (*WebhookSecretResponse, error) {
+	webhook, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = secret
+	webhook.UpdatedAt = time.Now().UnixNano()
+
+	err = m.dataStore.UpdateSecretByIdentifierAndActorAddressAndNodeIdentifier(ctx, webhook.Secret, webhook.Identifier, actorAddress, nodeIdentifier, webhook.UpdatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reject(http.StatusNotFound, "webhook %s not found", identifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookSecretResponse{Webhook: webhook, Secret: webhook.Secret}, nil
+}
+
+func (m *WebhookManager) Delete(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string) error {
+	err := m.dataStore.DeleteByIdentifierAndActorAddressAndNodeIdentifier(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reject(http.StatusNotFound, "webhook %s not found", identifier)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return m.dataStore.DeleteDeliveriesByWebhookIdentifier(ctx, identifier)
+}
+
+func (m *WebhookManager) Release(ctx context.Context, inboxIdentifier string, nodeIdentifier string) error {
+	return m.dataStore.DeleteByInboxIdentifierAndNodeIdentifier(ctx, inboxIdentifier, nodeIdentifier)
+}
+
+func (m *WebhookManager) ListDeliveries(ctx context.Context, identifier string, actorAddress string, nodeIdentifier string, pagination *api.Pagination) ([]*WebhookDelivery, error) {
+	webhook, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dataStore.FindDeliveriesByWebhookIdentifier(ctx, webhook.Identifier, pagination)
+}
+
+func (m *WebhookManager) GetDelivery(ctx context.Context, deliveryIdentifier string, identifier string, actorAddress string, nodeIdentifier string) (*WebhookDelivery, error) {
+	webhook, err := m.Get(ctx, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := m.dataStore.FindDeliveryByIdentifierAndWebhookIdentifier(ctx, deliveryIdentifier, webhook.Identifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reject(http.StatusNotFound, "webhook delivery %s not found", deliveryIdentifier)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (m *WebhookManager) Redeliver(ctx context.Context, deliveryIdentifier string, identifier string, actorAddress string, nodeIdentifier string) (*WebhookDelivery, error) {
+	delivery, err := m.GetDelivery(ctx, deliveryIdentifier, identifier, actorAddress, nodeIdentifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.Status != WebhookDeliveryStatusDelivered && delivery.Status != WebhookDeliveryStatusFailed {
+		return nil, reject(http.StatusConflict, "webhook delivery %s is still %s", deliveryIdentifier, delivery.Status)
+	}
+
+	now := time.Now().UnixNano()
+
+	err = m.dataStore.UpdateDeliveryStatusAndAttemptsAndStatusCodeAndLastErrorAndNextAttemptAtByIdentifier(ctx, WebhookDeliveryStatusPending, 0, delivery.StatusCode, delivery.LastError, now, delivery.Identifier, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Status = WebhookDeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+
+	m.notify()
+
+	return delivery, nil
+}
+
+func (m *WebhookManager) Dispatch(ctx context.Context, actorAddress string, nodeIdentifier string, inboxIdentifier string, event string, data interface{}) error {
+	webhooks, err := m.dataStore.FindByActorAddressAndNodeIdentifierAndEnabled(ctx, actorAddress, nodeIdentifier, true)
+
+	if err != nil {
+		return err
+	}
+
+	dispatched := false
+
+	for _, webhook := range webhooks {
+		if !webhook.subscribes(event, inboxIdentifier) {
+			continue
+		}
+
+		identifier, err := ids.Generate()
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UnixNano()
+
+		payload, err := json.Marshal(&WebhookPayload{
+			Identifier:        identifier,
+			Event:             event,
+			WebhookIdentifier: webhook.Identifier,
+			ActorAddress:      actorAddress,
+			InboxIdentifier:   inboxIdentifier,
+			Data:              data,
+			CreatedAt:         now,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		_, err = m.dataStore.InsertDelivery(ctx, &WebhookDelivery{
+			Identifier:        identifier,
+			WebhookIdentifier: webhook.Identifier,
+			Event:             event,
+			Payload:           string(payload),
+			Status:            WebhookDeliveryStatusPending,
+			NextAttemptAt:     now,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		dispatched = true
+	}
+
+	if dispatched {
+		m.notify()
+	}
+
+	return nil
+}
+
+func (m *WebhookManager) Notifications() <-chan struct{} {
+	return m.notifications
+}
+
+func (m *WebhookManager) notify() {
+	select {
+	case m.notifications <- struct{}{}:
+	default:
+	}
+}
+
+func (m *WebhookManager) Recover(ctx context.Context) error {
+	return m.dataStore.UpdateDeliveryStatusByStatus(ctx, WebhookDeliveryStatusPending, WebhookDeliveryStatusProcessing, time.Now().UnixNano())
+}
+
+func (m *WebhookManager) Claim(ctx context.Context) ([]*WebhookDelivery, error) {
+	due, err := m.dataStore.FindDueDeliveries(ctx, time.Now().UnixNano(), webhookBatchSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed []*WebhookDelivery
+
+	for _, delivery := range due {
+		err := m.dataStore.UpdateDeliveryStatusByIdentifierAndStatus(ctx, WebhookDeliveryStatusProcessing, delivery.Identifier, WebhookDeliveryStatusPending, time.Now().UnixNano())
+
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.Status = WebhookDeliveryStatusProcessing
+		claimed = append(claimed, delivery)
+	}
+
+	return claimed, nil
+}
+
+func (m *WebhookManager) Attempt(ctx context.Context, delivery *WebhookDelivery) {
+	attemptCtx, cancel := context.WithTimeout(ctx, webhookAttemptTimeout)
+	defer cancel()
+
+	statusCode, retryable, deliveryErr := m.deliver(attemptCtx, delivery)
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	status := WebhookDeliveryStatusDelivered
+	lastError := ""
+	nextAttemptAt := now.UnixNano()
+
+	if deliveryErr != nil {
+		zap.L().Warn("webhook delivery attempt failed",
+			zap.String("delivery", delivery.Identifier),
+			zap.String("webhook", delivery.WebhookIdentifier),
+			zap.Int64("attempts", attempts),
+			zap.Error(deliveryErr))
+
+		status = WebhookDeliveryStatusPending
+		lastError = truncate(deliveryErr.Error(), webhookMaxErrorLength)
+		nextAttemptAt = now.Add(backoff(attempts)).UnixNano()
+
+		if !retryable || attempts >= m.maxAttempts {
+			status = WebhookDeliveryStatusFailed
+		}
+	}
+
+	err := m.dataStore.UpdateDeliveryStatusAndAttemptsAndStatusCodeAndLastErrorAndNextAttemptAtByIdentifier(ctx, status, attempts, statusCode, lastError, nextAttemptAt, delivery.Identifier, now.UnixNano())
+
+	if err != nil {
+		zap.L().Error("error updating webhook delivery", zap.String("delivery", delivery.Identifier), zap.Error(err))
+	}
+}
+
+func (m *WebhookManager) deliver(ctx context.Context, delivery *WebhookDelivery) (int, bool, error) {
+	webhook, err := m.dataStore.FindByIdentifier(ctx, delivery.WebhookIdentifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, fmt.Errorf("webhook %s no longer exists", delivery.WebhookIdentifier)
+	}
+
+	if err != nil {
+		return 0, true, err
+	}
+
+	if !webhook.Enabled {
+		return 0, false, fmt.Errorf("webhook %s is disabled", webhook.Identifier)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader([]byte(delivery.Payload)))
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "evernet-vertex")
+	request.Header.Set(api.WebhookEventHeader, delivery.Event)
+	request.Header.Set(api.WebhookDeliveryHeader, delivery.Identifier)
+	request.Header.Set(api.WebhookSignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, signWebhookPayload(webhook.Secret, timestamp, delivery.Payload)))
+
+	response, err := m.client.Do(request)
+
+	if err != nil {
+		return 0, true, err
+	}
+
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(response.Body)
+
+	if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, webhookMaxResponseRead))
+		return response.StatusCode, false, nil
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, webhookMaxResponseRead))
+	retryable := response.StatusCode >= http.StatusInternalServerError ||
+		response.StatusCode == http.StatusRequestTimeout ||
+		response.StatusCode == http.StatusTooManyRequests
+
+	return response.StatusCode, retryable, fmt.Errorf("webhook responded with status %d", response.StatusCode)
+}
+
+func (m *WebhookManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cutoff := time.Now().Add(-webhookLogRetention).UnixNano()
+		err := m.dataStore.DeleteDeliveriesByStatusInAndCreatedAtBefore(ctx, []string{WebhookDeliveryStatusDelivered, WebhookDeliveryStatusFailed}, cutoff)
+
+		if err != nil {
+			zap.L().Error("error purging webhook deliveries", zap.Error(err))
+		}
+	}
+}
+
+func (m *WebhookManager) validate(ctx context.Context, request *WebhookRequest, actorAddress string, nodeIdentifier string) error {
+	target, err := url.Parse(request.URL)
+
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return reject(http.StatusBadRequest, "invalid webhook url %s", request.URL)
+	}
+
+	err = m.addressPolicy.Validate(target.Hostname())
+
+	if err != nil {
+		return reject(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	for _, event := range request.Events {
+		if !isValidWebhookEvent(event) {
+			return reject(http.StatusBadRequest, "invalid webhook event %s", event)
+		}
+	}
+
+	if request.InboxIdentifier != "" {
+		_, err = m.inboxDataStore.FindByIdentifierAndActorAddressAndNodeIdentifier(ctx, request.InboxIdentifier, actorAddress, nodeIdentifier)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return reject(http.StatusBadRequest, "inbox %s not found", request.InboxIdentifier)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func signWebhookPayload(secret string, timestamp int64, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.%s", timestamp, payload)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func uniqueEvents(events []string) []string {
+	var unique []string
+
+	for _, event := range events {
+		if !slices.Contains(unique, event) {
+			unique = append(unique, event)
+		}
+	}
+
+	return unique
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+
+	return value[:length]
+}
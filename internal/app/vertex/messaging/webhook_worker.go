@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const webhookPollInterval = time.Second
+
+type WebhookWorker struct {
+	manager     *WebhookManager
+	concurrency int
+}
+
+func NewWebhookWorker(manager *WebhookManager, concurrency int) *WebhookWorker {
+	return &WebhookWorker{manager: manager, concurrency: concurrency}
+}
+
+func (w *WebhookWorker) Run(ctx context.Context) {
+	err := w.manager.Recover(ctx)
+
+	if err != nil {
+		zap.L().Error("error recovering webhook deliveries", zap.Error(err))
+	}
+
+	queue := make(chan *WebhookDelivery)
+	var wg sync.WaitGroup
+
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range queue {
+				w.manager.Attempt(ctx, delivery)
+			}
+		}()
+	}
+
+	defer func() {
+		close(queue)
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.manager.Notifications():
+		}
+
+		deliveries, err := w.manager.Claim(ctx)
+
+		if err != nil {
+			zap.L().Error("error claiming webhook deliveries", zap.Error(err))
+			continue
+		}
+
+		for _, delivery := range deliveries {
+			select {
+			case queue <- delivery:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package node
 import (
 	"context"
 	"database/sql"
+	"github.com/evernetproto/evernet/internal/pkg/api"
 	"go.uber.org/zap"
 )
 
@@ -32,10 +33,11 @@ func (d *DataStore) Insert(ctx context.Context, node *Node) (*Node, error) {
 	return node, nil
 }
 
-func (d *DataStore) FindAll(ctx context.Context, page int64, size int64) ([]*Node, error) {
+func (d *DataStore) FindAll(ctx context.Context, pagination *api.Pagination) ([]*Node, error) {
+	createdAt, identifier := pagination.Bounds(false)
 	rows, err := d.db.QueryContext(ctx,
-		"SELECT identifier, display_name, signing_private_key, signing_public_key, creator, created_at, updated_at FROM nodes LIMIT ? OFFSET ?",
-		size, page*size)
+		"SELECT identifier, display_name, signing_private_key, signing_public_key, creator, retention_max_age, retention_max_count, created_at, updated_at FROM nodes WHERE (created_at, identifier) > (?, ?) ORDER BY created_at, identifier LIMIT ? OFFSET ?",
+		createdAt, identifier, pagination.Size, pagination.Offset())
 
 	if err != nil {
 		return nil, err
@@ -52,7 +54,7 @@ func (d *DataStore) FindAll(ctx context.Context, page int64, size int64) ([]*Nod
 
 	for rows.Next() {
 		var node Node
-		err = rows.Scan(&node.Identifier, &node.DisplayName, &node.SigningPrivateKey, &node.SigningPublicKey, &node.Creator, &node.CreatedAt, &node.UpdatedAt)
+		err = rows.Scan(&node.Identifier, &node.DisplayName, &node.SigningPrivateKey, &node.SigningPublicKey, &node.Creator, &node.RetentionMaxAge, &node.RetentionMaxCount, &node.CreatedAt, &node.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -69,7 +71,7 @@ func (d *DataStore) FindAll(ctx context.Context, page int64, size int64) ([]*Nod
 func (d *DataStore) FindByIdentifier(ctx context.Context, identifier string) (*Node, error) {
 	var node Node
 	err := d.db.QueryRowContext(ctx,
-		"SELECT identifier, display_name, signing_private_key, signing_public_key, creator, created_at, updated_at FROM nodes WHERE identifier = ?",
+		"SELECT identifier, display_name, signing_private_key, signing_public_key, creator, retention_max_age, retention_max_count, created_at, updated_at FROM nodes WHERE identifier = ?",
 		identifier).
 		Scan(
 			&node.Identifier,
@@ -77,6 +79,8 @@ func (d *DataStore) FindByIdentifier(ctx context.Context, identifier string) (*N
 			&node.SigningPrivateKey,
 			&node.SigningPublicKey,
 			&node.Creator,
+			&node.RetentionMaxAge,
+			&node.RetentionMaxCount,
 			&node.CreatedAt,
 			&node.UpdatedAt,
 		)
@@ -130,6 +134,27 @@ func (d *DataStore) UpdateSigningPrivateKeyAndSigningPublicKeyByIdentifier(ctx c
 	return nil
 }
 
+func (d *DataStore) UpdateRetentionByIdentifier(ctx context.Context, retentionMaxAge int64, retentionMaxCount int64, identifier string) error {
+	result, err := d.db.ExecContext(ctx,
+		"UPDATE nodes SET retention_max_age = ?, retention_max_count = ? WHERE identifier = ?",
+		retentionMaxAge, retentionMaxCount, identifier)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 func (d *DataStore) DeleteByIdentifier(ctx context.Context, identifier string) error {
 	result, err := d.db.ExecContext(ctx, "DELETE FROM nodes WHERE identifier = ?", identifier)
 
@@ -3,20 +3,24 @@ package node
 import (
 	"context"
 	"github.com/evernetproto/evernet/internal/app/vertex/admin"
+	"github.com/evernetproto/evernet/internal/app/vertex/idempotency"
 	"github.com/evernetproto/evernet/internal/pkg/api"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"time"
 )
 
+const nodeCacheControl = "public, max-age=300"
+
 type Handler struct {
-	router        *gin.Engine
-	authenticator *admin.Authenticator
-	manager       *Manager
+	router             *gin.Engine
+	authenticator      *admin.Authenticator
+	manager            *Manager
+	idempotencyManager *idempotency.Manager
 }
 
-func NewHandler(router *gin.Engine, authenticator *admin.Authenticator, manager *Manager) *Handler {
-	return &Handler{router: router, authenticator: authenticator, manager: manager}
+func NewHandler(router *gin.Engine, authenticator *admin.Authenticator, manager *Manager, idempotencyManager *idempotency.Manager) *Handler {
+	return &Handler{router: router, authenticator: authenticator, manager: manager, idempotencyManager: idempotencyManager}
 }
 
 func (h *Handler) Register() {
@@ -31,6 +35,10 @@ func (h *Handler) Register() {
 			return
 		}
 
+		if h.idempotencyManager.Replay(ctx, c, "admin:"+authenticatedAdmin.Identifier) {
+			return
+		}
+
 		var request CreationRequest
 		if err := c.ShouldBindJSON(&request); err != nil {
 			api.Error(c, http.StatusBadRequest, err)
@@ -51,16 +59,22 @@ func (h *Handler) Register() {
 		ctx, cancel := context.WithTimeout(c, 5*time.Second)
 		defer cancel()
 
-		page, size := api.Page(c)
+		pagination, err := api.Paginate(c)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
 
-		nodes, err := h.manager.List(ctx, page, size)
+		nodes, err := h.manager.List(ctx, pagination)
 
 		if err != nil {
 			api.Error(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, nodes)
+		api.Paginated(c, http.StatusOK, pagination, nodes, func(node *Node) *api.Cursor {
+			return &api.Cursor{CreatedAt: node.CreatedAt, Identifier: node.Identifier}
+		})
 	})
 
 	h.router.GET("/api/v1/nodes/:nodeIdentifier", func(c *gin.Context) {
@@ -74,6 +88,7 @@ func (h *Handler) Register() {
 			return
 		}
 
+		c.Header("Cache-Control", nodeCacheControl)
 		c.JSON(http.StatusOK, node)
 	})
 
@@ -103,6 +118,32 @@ func (h *Handler) Register() {
 		api.Success(c, http.StatusOK, "node updated successfully")
 	})
 
+	h.router.PUT("/api/v1/nodes/:nodeIdentifier/retention", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		var request RetentionUpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		identifier := c.Param("nodeIdentifier")
+		err = h.manager.UpdateRetention(ctx, identifier, &request)
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "node retention updated successfully")
+	})
+
 	h.router.DELETE("/api/v1/nodes/:nodeIdentifier", func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c, 5*time.Second)
 		defer cancel()
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/api"
 	"github.com/evernetproto/evernet/internal/pkg/keys"
 	"time"
 )
@@ -47,8 +48,8 @@ func (m *Manager) Create(ctx context.Context, request *CreationRequest, creator
 	return m.dataStore.Insert(ctx, node)
 }
 
-func (m *Manager) List(ctx context.Context, page int64, size int64) ([]*Node, error) {
-	return m.dataStore.FindAll(ctx, page, size)
+func (m *Manager) List(ctx context.Context, pagination *api.Pagination) ([]*Node, error) {
+	return m.dataStore.FindAll(ctx, pagination)
 }
 
 func (m *Manager) Get(ctx context.Context, identifier string) (*Node, error) {
@@ -71,6 +72,16 @@ func (m *Manager) Update(ctx context.Context, identifier string, request *Update
 	return err
 }
 
+func (m *Manager) UpdateRetention(ctx context.Context, identifier string, request *RetentionUpdateRequest) error {
+	err := m.dataStore.UpdateRetentionByIdentifier(ctx, request.MaxAge, request.MaxCount, identifier)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("node %s not found", identifier)
+	}
+
+	return err
+}
+
 func (m *Manager) Delete(ctx context.Context, identifier string) error {
 	err := m.dataStore.DeleteByIdentifier(ctx, identifier)
 
@@ -12,6 +12,8 @@ type Node struct {
 	SigningPrivateKey string `json:"-" db:"signing_private_key"`
 	SigningPublicKey  string `json:"signing_public_key" db:"signing_public_key"`
 	Creator           string `json:"creator" db:"creator"`
+	RetentionMaxAge   int64  `json:"retention_max_age" db:"retention_max_age"`
+	RetentionMaxCount int64  `json:"retention_max_count" db:"retention_max_count"`
 	CreatedAt         int64  `json:"created_at" db:"created_at"`
 	UpdatedAt         int64  `json:"updated_at" db:"updated_at"`
 }
@@ -2,63 +2,137 @@ package node
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/evernetproto/evernet/internal/app/vertex/discovery"
+	"github.com/evernetproto/evernet/internal/pkg/cache"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
 	"go.uber.org/zap"
-	"io"
 	"net/http"
 	"time"
 )
 
+const (
+	remoteNodeMaxTTL             = 24 * time.Hour
+	remoteNodeMinRefreshInterval = 30 * time.Second
+	remoteNodeCacheSize          = 10000
+)
+
+type remoteNodeEntry struct {
+	node      *Node
+	fetchedAt time.Time
+	expiresAt time.Time
+}
+
 type RemoteManager struct {
-	httpClient *http.Client
+	federationClient *federation.Client
+	discoveryManager *discovery.RemoteManager
+	ttl              time.Duration
+	maxStale         time.Duration
+	cache            *cache.LRU[string, *remoteNodeEntry]
 }
 
-func NewRemoteManager() *RemoteManager {
+func NewRemoteManager(federationClient *federation.Client, discoveryManager *discovery.RemoteManager, ttl time.Duration, maxStale time.Duration) *RemoteManager {
 	return &RemoteManager{
-		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
-		},
+		federationClient: federationClient,
+		discoveryManager: discoveryManager,
+		ttl:              ttl,
+		maxStale:         maxStale,
+		cache:            cache.NewLRU[string, *remoteNodeEntry](remoteNodeCacheSize),
 	}
 }
 
 func (m *RemoteManager) Get(ctx context.Context, nodeVertex string, nodeIdentifier string) (*Node, error) {
-	url := fmt.Sprintf("https://%s/api/v1/nodes/%s", nodeVertex, nodeIdentifier)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	key := remoteNodeKey(nodeVertex, nodeIdentifier)
+	entry := m.lookup(key)
+	now := time.Now()
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if entry != nil && now.Before(entry.expiresAt) {
+		return entry.node, nil
 	}
 
-	resp, err := m.httpClient.Do(req)
+	node, err := m.fetch(ctx, nodeVertex, nodeIdentifier)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to make GET request: %w", err)
+		if entry != nil && isStaleable(err) && now.Before(entry.expiresAt.Add(m.maxStale)) {
+			zap.L().Warn("serving stale remote node", zap.String("vertex", nodeVertex), zap.String("node", nodeIdentifier), zap.Error(err))
+			return entry.node, nil
+		}
+
+		return nil, err
 	}
 
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			zap.L().Error("failed to close response body", zap.Error(err))
-		}
-	}(resp.Body)
+	return node, nil
+}
+
+func (m *RemoteManager) Refresh(ctx context.Context, nodeVertex string, nodeIdentifier string) (*Node, error) {
+	entry := m.lookup(remoteNodeKey(nodeVertex, nodeIdentifier))
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	if entry != nil && time.Since(entry.fetchedAt) < remoteNodeMinRefreshInterval {
+		return entry.node, nil
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	return m.fetch(ctx, nodeVertex, nodeIdentifier)
+}
+
+func (m *RemoteManager) fetch(ctx context.Context, nodeVertex string, nodeIdentifier string) (*Node, error) {
+	key := remoteNodeKey(nodeVertex, nodeIdentifier)
+
+	url, err := m.discoveryManager.URL(ctx, nodeVertex, discovery.EndpointNode, nodeIdentifier)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
 	var node Node
 
-	if err := json.Unmarshal(body, &node); err != nil {
-		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	header, err := m.federationClient.GetWithHeader(ctx, url, "", &node)
+
+	var statusError *federation.StatusError
+
+	if errors.As(err, &statusError) && statusError.StatusCode == http.StatusNotFound {
+		m.evict(key)
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, store := federation.CacheLifetime(header, m.ttl, remoteNodeMaxTTL)
+
+	if !store {
+		m.evict(key)
+		return &node, nil
+	}
+
+	now := time.Now()
+
+	m.cache.Put(key, &remoteNodeEntry{node: &node, fetchedAt: now, expiresAt: now.Add(ttl)})
+
 	return &node, nil
 }
+
+func (m *RemoteManager) lookup(key string) *remoteNodeEntry {
+	entry, _ := m.cache.Get(key)
+	return entry
+}
+
+func (m *RemoteManager) evict(key string) {
+	m.cache.Remove(key)
+}
+
+func isStaleable(err error) bool {
+	var statusError *federation.StatusError
+
+	if !errors.As(err, &statusError) {
+		return true
+	}
+
+	return statusError.StatusCode >= http.StatusInternalServerError ||
+		statusError.StatusCode == http.StatusRequestTimeout ||
+		statusError.StatusCode == http.StatusTooManyRequests
+}
+
+func remoteNodeKey(nodeVertex string, nodeIdentifier string) string {
+	return fmt.Sprintf("%s/%s", nodeVertex, nodeIdentifier)
+}
@@ -8,3 +8,8 @@ type CreationRequest struct {
 type UpdateRequest struct {
 	DisplayName string `json:"display_name" binding:"required"`
 }
+
+type RetentionUpdateRequest struct {
+	MaxAge   int64 `json:"max_age" binding:"min=0"`
+	MaxCount int64 `json:"max_count" binding:"min=0"`
+}
@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"go.uber.org/zap"
+)
+
+type DataStore struct {
+	db *sql.DB
+}
+
+func NewDataStore(db *sql.DB) *DataStore {
+	return &DataStore{db: db}
+}
+
+func (d *DataStore) Upsert(ctx context.Context, policy *Policy) (*Policy, error) {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO federation_policies (vertex, mode, updated_by, updated_at) VALUES (?, ?, ?, ?) ON CONFLICT (vertex) DO UPDATE SET mode = excluded.mode, updated_by = excluded.updated_by, updated_at = excluded.updated_at",
+		policy.Vertex,
+		policy.Mode,
+		policy.UpdatedBy,
+		policy.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (d *DataStore) FindByVertex(ctx context.Context, vertex string) (*Policy, error) {
+	var policy Policy
+	err := d.db.QueryRowContext(ctx,
+		"SELECT vertex, mode, updated_by, updated_at FROM federation_policies WHERE vertex = ?",
+		vertex).
+		Scan(&policy.Vertex, &policy.Mode, &policy.UpdatedBy, &policy.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (d *DataStore) InsertEntry(ctx context.Context, entry *Entry) (*Entry, error) {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO federation_policy_entries (list, entry, creator, created_at) VALUES (?, ?, ?, ?) ON CONFLICT (list, entry) DO NOTHING",
+		entry.List,
+		entry.Entry,
+		entry.Creator,
+		entry.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (d *DataStore) FindEntriesByList(ctx context.Context, list string) ([]*Entry, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT list, entry, creator, created_at FROM federation_policy_entries WHERE list = ? ORDER BY entry",
+		list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zap.L().Error("error closing rows", zap.Error(err))
+		}
+	}(rows)
+
+	var entries []*Entry
+
+	for rows.Next() {
+		var entry Entry
+		err = rows.Scan(&entry.List, &entry.Entry, &entry.Creator, &entry.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (d *DataStore) ExistsEntryByListAndEntry(ctx context.Context, list string, entry string) (bool, error) {
+	var count int64
+
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM federation_policy_entries WHERE list = ? AND entry = ?",
+		list, entry).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *DataStore) DeleteEntryByListAndEntry(ctx context.Context, list string, entry string) error {
+	result, err := d.db.ExecContext(ctx,
+		"DELETE FROM federation_policy_entries WHERE list = ? AND entry = ?",
+		list, entry)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"context"
+	"github.com/evernetproto/evernet/internal/app/vertex/admin"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	router        *gin.Engine
+	authenticator *admin.Authenticator
+	manager       *Manager
+}
+
+func NewHandler(router *gin.Engine, authenticator *admin.Authenticator, manager *Manager) *Handler {
+	return &Handler{router: router, authenticator: authenticator, manager: manager}
+}
+
+func (h *Handler) Register() {
+
+	h.router.GET("/api/v1/federation/policy", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		policy, err := h.manager.Get(ctx)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, policy)
+	})
+
+	h.router.PUT("/api/v1/federation/policy", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedAdmin, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		var request UpdateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		policy, err := h.manager.Update(ctx, &request, authenticatedAdmin.Identifier)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, policy)
+	})
+
+	h.registerList("allowlist", ListAllow)
+	h.registerList("denylist", ListDeny)
+}
+
+func (h *Handler) registerList(name string, list string) {
+
+	h.router.POST("/api/v1/federation/policy/"+name, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		authenticatedAdmin, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		var request EntryRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		entry, err := h.manager.AddEntry(ctx, list, &request, authenticatedAdmin.Identifier)
+
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, entry)
+	})
+
+	h.router.GET("/api/v1/federation/policy/"+name, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		entries, err := h.manager.ListEntries(ctx, list)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	})
+
+	h.router.DELETE("/api/v1/federation/policy/"+name, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		_, err := h.authenticator.ValidateContext(c)
+		if err != nil {
+			api.Error(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		entry := c.Query("entry")
+
+		if entry == "" {
+			api.ErrorMessage(c, http.StatusBadRequest, "entry is required")
+			return
+		}
+
+		err = h.manager.RemoveEntry(ctx, list, entry)
+
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		api.Success(c, http.StatusOK, "policy entry removed successfully")
+	})
+}
@@ -0,0 +1,175 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Manager struct {
+	vertex    string
+	dataStore *DataStore
+}
+
+func NewManager(vertex string, dataStore *DataStore) *Manager {
+	return &Manager{vertex: vertex, dataStore: dataStore}
+}
+
+func (m *Manager) Get(ctx context.Context) (*Policy, error) {
+	policy, err := m.dataStore.FindByVertex(ctx, m.vertex)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Policy{Vertex: m.vertex, Mode: ModeOpen}, nil
+	}
+
+	return policy, err
+}
+
+func (m *Manager) Update(ctx context.Context, request *UpdateRequest, updatedBy string) (*Policy, error) {
+	return m.dataStore.Upsert(ctx, &Policy{
+		Vertex:    m.vertex,
+		Mode:      request.Mode,
+		UpdatedBy: updatedBy,
+		UpdatedAt: time.Now().UnixNano(),
+	})
+}
+
+func (m *Manager) AddEntry(ctx context.Context, list string, request *EntryRequest, creator string) (*Entry, error) {
+	if !isValidEntry(request.Entry) {
+		return nil, fmt.Errorf("invalid policy entry %s", request.Entry)
+	}
+
+	entry := normalizeEntry(request.Entry)
+
+	if strings.Split(entry, "/")[0] == NormalizeVertex(m.vertex) {
+		return nil, fmt.Errorf("policy entry %s refers to this vertex", request.Entry)
+	}
+
+	return m.dataStore.InsertEntry(ctx, &Entry{
+		List:      list,
+		Entry:     entry,
+		Creator:   creator,
+		CreatedAt: time.Now().UnixNano(),
+	})
+}
+
+func (m *Manager) ListEntries(ctx context.Context, list string) ([]*Entry, error) {
+	return m.dataStore.FindEntriesByList(ctx, list)
+}
+
+func (m *Manager) RemoveEntry(ctx context.Context, list string, entry string) error {
+	err := m.dataStore.DeleteEntryByListAndEntry(ctx, list, normalizeEntry(entry))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("policy entry %s not found", entry)
+	}
+
+	return err
+}
+
+func (m *Manager) Check(ctx context.Context, vertex string, nodeIdentifier string) error {
+	vertex = NormalizeVertex(vertex)
+
+	if vertex == NormalizeVertex(m.vertex) {
+		return nil
+	}
+
+	peer := vertex
+
+	if nodeIdentifier != "" {
+		peer = fmt.Sprintf("%s/%s", vertex, nodeIdentifier)
+
+		denied, err := m.dataStore.ExistsEntryByListAndEntry(ctx, ListDeny, peer)
+
+		if err != nil {
+			return err
+		}
+
+		if denied {
+			return &BlockedError{Peer: peer}
+		}
+
+		allowed, err := m.dataStore.ExistsEntryByListAndEntry(ctx, ListAllow, peer)
+
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			return nil
+		}
+	}
+
+	policy, err := m.Get(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	switch policy.Mode {
+	case ModeAllowlist:
+		allowed, err := m.dataStore.ExistsEntryByListAndEntry(ctx, ListAllow, vertex)
+
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			return &BlockedError{Peer: peer}
+		}
+	case ModeDenylist:
+		denied, err := m.dataStore.ExistsEntryByListAndEntry(ctx, ListDeny, vertex)
+
+		if err != nil {
+			return err
+		}
+
+		if denied {
+			return &BlockedError{Peer: peer}
+		}
+	}
+
+	return nil
+}
+
+func isValidEntry(entry string) bool {
+	components := strings.Split(entry, "/")
+
+	if len(components) > 2 {
+		return false
+	}
+
+	for _, component := range components {
+		if component == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func NormalizeVertex(vertex string) string {
+	vertex = strings.ToLower(vertex)
+
+	for _, port := range []string{":443", ":80"} {
+		if strings.HasSuffix(vertex, port) {
+			return strings.TrimSuffix(vertex, port)
+		}
+	}
+
+	return vertex
+}
+
+func normalizeEntry(entry string) string {
+	vertex, nodeIdentifier, ok := strings.Cut(entry, "/")
+	vertex = NormalizeVertex(vertex)
+
+	if !ok {
+		return vertex
+	}
+
+	return vertex + "/" + nodeIdentifier
+}
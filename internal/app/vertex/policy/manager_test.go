@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"github.com/evernetproto/evernet/internal/app/vertex/db"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	err := os.Chdir("../../../..")
+
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	database := db.MigrateDatabase(filepath.Join(t.TempDir(), "vertex.db"), "vertex")
+
+	t.Cleanup(func() {
+		_ = database.Close()
+	})
+
+	return NewManager("home.example", NewDataStore(database))
+}
+
+func TestNormalizeVertex(t *testing.T) {
+	tests := map[string]string{
+		"Example.COM":       "example.com",
+		"example.com:443":   "example.com",
+		"example.com:80":    "example.com",
+		"example.com:8443":  "example.com:8443",
+		"example.com:8080":  "example.com:8080",
+		"[::1]:443":         "[::1]",
+		"127.0.0.1:9876":    "127.0.0.1:9876",
+		"EXAMPLE.com:80443": "example.com:80443",
+	}
+
+	for vertex, expected := range tests {
+		if normalized := NormalizeVertex(vertex); normalized != expected {
+			t.Errorf("NormalizeVertex(%q): expected %q, got %q", vertex, expected, normalized)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		mode    string
+		allow   []string
+		deny    []string
+		vertex  string
+		node    string
+		blocked bool
+	}{
+		{"open", ModeOpen, nil, nil, "peer.example", "node", false},
+		{"own vertex", ModeAllowlist, nil, nil, "HOME.example:443", "node", false},
+		{"denied vertex in open mode is ignored", ModeOpen, nil, []string{"peer.example"}, "peer.example", "node", false},
+		{"denied node in open mode", ModeOpen, nil, []string{"peer.example/node"}, "peer.example", "node", true},
+		{"other node in open mode", ModeOpen, nil, []string{"peer.example/node"}, "peer.example", "other", false},
+		{"allowlist without entry", ModeAllowlist, nil, nil, "peer.example", "node", true},
+		{"allowlisted vertex", ModeAllowlist, []string{"peer.example"}, nil, "peer.example", "node", false},
+		{"allowlisted node", ModeAllowlist, []string{"peer.example/node"}, nil, "peer.example", "node", false},
+		{"allowlisted node only", ModeAllowlist, []string{"peer.example/node"}, nil, "peer.example", "other", true},
+		{"allowlisted vertex with denied node", ModeAllowlist, []string{"peer.example"}, []string{"peer.example/node"}, "peer.example", "node", true},
+		{"denylisted vertex", ModeDenylist, nil, []string{"peer.example"}, "peer.example", "node", true},
+		{"denylisted vertex with allowed node", ModeDenylist, []string{"peer.example/node"}, []string{"peer.example"}, "peer.example", "node", false},
+		{"denylisted vertex for vertex-only peer", ModeDenylist, nil, []string{"peer.example"}, "peer.example", "", true},
+		{"denylist case and port", ModeDenylist, nil, []string{"Peer.Example:443"}, "PEER.example", "node", true},
+		{"denylist default http port", ModeDenylist, nil, []string{"peer.example"}, "peer.example:80", "node", true},
+		{"denylist other port", ModeDenylist, nil, []string{"peer.example"}, "peer.example:8443", "node", false},
+		{"allowlist case and port", ModeAllowlist, []string{"PEER.EXAMPLE:443/node"}, nil, "peer.example", "node", false},
+	}
+
+	for _, test := range tests {
+		manager := newTestManager(t)
+
+		_, err := manager.Update(ctx, &UpdateRequest{Mode: test.mode}, "root")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for list, entries := range map[string][]string{ListAllow: test.allow, ListDeny: test.deny} {
+			for _, entry := range entries {
+				_, err := manager.AddEntry(ctx, list, &EntryRequest{Entry: entry}, "root")
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		err = manager.Check(ctx, test.vertex, test.node)
+
+		var blockedError *BlockedError
+
+		if test.blocked != errors.As(err, &blockedError) {
+			t.Errorf("%s: expected blocked=%t, got %v", test.name, test.blocked, err)
+		}
+
+		if !test.blocked && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
+
+func TestAddEntryNormalizes(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestManager(t)
+
+	entry, err := manager.AddEntry(ctx, ListDeny, &EntryRequest{Entry: "Peer.Example:443/Node"}, "root")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Entry != "peer.example/Node" {
+		t.Fatalf("expected normalized entry, got %q", entry.Entry)
+	}
+
+	_, err = manager.AddEntry(ctx, ListDeny, &EntryRequest{Entry: "HOME.example:80"}, "root")
+
+	if err == nil {
+		t.Fatal("expected an entry for this vertex to be rejected")
+	}
+
+	err = manager.RemoveEntry(ctx, ListDeny, "peer.example:443/Node")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,36 @@
+package policy
+
+import "fmt"
+
+type Policy struct {
+	Vertex    string `json:"vertex" db:"vertex"`
+	Mode      string `json:"mode" db:"mode"`
+	UpdatedBy string `json:"updated_by" db:"updated_by"`
+	UpdatedAt int64  `json:"updated_at" db:"updated_at"`
+}
+
+const (
+	ModeOpen      = "open"
+	ModeAllowlist = "allowlist"
+	ModeDenylist  = "denylist"
+)
+
+type Entry struct {
+	List      string `json:"list" db:"list"`
+	Entry     string `json:"entry" db:"entry"`
+	Creator   string `json:"creator" db:"creator"`
+	CreatedAt int64  `json:"created_at" db:"created_at"`
+}
+
+const (
+	ListAllow = "allow"
+	ListDeny  = "deny"
+)
+
+type BlockedError struct {
+	Peer string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("federation with %s is blocked by policy", e.Peer)
+}
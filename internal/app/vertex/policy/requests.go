@@ -0,0 +1,9 @@
+package policy
+
+type UpdateRequest struct {
+	Mode string `json:"mode" binding:"required,oneof=open allowlist denylist"`
+}
+
+type EntryRequest struct {
+	Entry string `json:"entry" binding:"required"`
+}
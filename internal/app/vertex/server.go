@@ -1,44 +1,90 @@
 package vertex
 
 import (
+	"context"
+	"crypto/ed25519"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/evernetproto/evernet/internal/app/vertex/actor"
 	"github.com/evernetproto/evernet/internal/app/vertex/admin"
 	"github.com/evernetproto/evernet/internal/app/vertex/db"
+	"github.com/evernetproto/evernet/internal/app/vertex/discovery"
 	"github.com/evernetproto/evernet/internal/app/vertex/health"
+	"github.com/evernetproto/evernet/internal/app/vertex/idempotency"
 	"github.com/evernetproto/evernet/internal/app/vertex/messaging"
 	"github.com/evernetproto/evernet/internal/app/vertex/node"
+	"github.com/evernetproto/evernet/internal/app/vertex/policy"
+	"github.com/evernetproto/evernet/internal/pkg/federation"
+	"github.com/evernetproto/evernet/internal/pkg/keys"
 	"github.com/evernetproto/evernet/internal/pkg/logger"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 )
 
 type Server struct {
-	config *ServerConfig
+	config     *ServerConfig
+	httpServer *http.Server
+	ctx        context.Context
+	cancel     context.CancelFunc
+	workers    sync.WaitGroup
+	database   *sql.DB
 }
 
 func NewServer(config *ServerConfig) *Server {
-	return &Server{config: config}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Server{
+		config: config,
+		httpServer: &http.Server{
+			Addr: fmt.Sprintf("%s:%s", config.Host, config.Port),
+		},
+		ctx:    ctx,
+		cancel: cancel,
+	}
 }
 
 type ServerConfig struct {
-	Host          string
-	Port          string
-	Vertex        string
-	DataPath      string
-	StaticPath    string
-	JwtSigningKey string
+	Host                       string
+	Port                       string
+	Vertex                     string
+	DataPath                   string
+	StaticPath                 string
+	JwtSigningKey              string
+	FederationScheme           string
+	FederationAllowInsecure    bool
+	DeliveryWorkers            int
+	DeliveryMaxAttempts        int
+	RetentionInterval          int
+	IdempotencyWindow          int
+	IdempotencyLease           int
+	WebhookWorkers             int
+	WebhookMaxAttempts         int
+	WebhookAllowedHosts        []string
+	SessionAllowedOrigins      []string
+	RemoteNodeCacheTTL         int
+	RemoteNodeMaxStale         int
+	FederationInsecureVertices []string
+	FederationCAFile           string
+	FederationClientCertFile   string
+	FederationClientKeyFile    string
+	FederationTimeout          int
+	FederationPeerTimeouts     map[string]int
+	FederationRequireSigned    bool
 }
 
 const (
 	ServiceName      = "vertex"
 	MetaDatabaseFile = "meta.db"
 	MetaDatabase     = "metabase"
+	VertexKeyFile    = "vertex.key"
 )
 
 func (s *Server) Start() {
@@ -47,20 +93,34 @@ func (s *Server) Start() {
 		_ = zap.L().Sync()
 	}()
 
+	s.httpServer.Handler = s.Handler()
+
+	zap.L().Info("starting vertex", zap.String("host", s.config.Host), zap.String("port", s.config.Port))
+	err := s.httpServer.ListenAndServe()
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		zap.L().Panic("error while starting vertex", zap.Error(err))
+	}
+
+	s.Close()
+}
+
+func (s *Server) Handler() http.Handler {
 	err := os.MkdirAll(s.config.DataPath, os.ModePerm)
 
 	if err != nil {
 		zap.L().Error("error creating data directory", zap.Error(err))
 	}
 
+	vertexSigningKey, err := keys.LoadOrGenerateED25519PrivateKey(filepath.Join(s.config.DataPath, VertexKeyFile))
+
+	if err != nil {
+		zap.L().Fatal("error loading vertex signing key", zap.Error(err))
+	}
+
 	metaDatabasePath := filepath.Join(s.config.DataPath, MetaDatabaseFile)
 	database := db.MigrateDatabase(metaDatabasePath, MetaDatabase)
-	defer func(db *sql.DB) {
-		err := db.Close()
-		if err != nil {
-			zap.L().Fatal("error closing sqlite database", zap.Error(err))
-		}
-	}(database)
+	s.database = database
 
 	router := gin.Default()
 	router.Use(static.Serve("/", static.LocalFile(s.config.StaticPath, true)))
@@ -80,27 +140,137 @@ func (s *Server) Start() {
 	actorDataStore := actor.NewDataStore(database)
 	inboxDataStore := messaging.NewInboxDataStore(database)
 	outboxDataStore := messaging.NewOutboxDataStore(database)
+	messageDataStore := messaging.NewMessageDataStore(database)
+	deliveryDataStore := messaging.NewDeliveryDataStore(database)
+	inboxEventDataStore := messaging.NewInboxEventDataStore(database)
+	blobDataStore := messaging.NewBlobDataStore(database)
+	inboxRuleDataStore := messaging.NewInboxRuleDataStore(database)
+	inboxAccessDataStore := messaging.NewInboxAccessDataStore(database)
+	idempotencyDataStore := idempotency.NewDataStore(database)
+	messageRevisionDataStore := messaging.NewMessageRevisionDataStore(database)
+	webhookDataStore := messaging.NewWebhookDataStore(database)
+	policyDataStore := policy.NewDataStore(database)
+	discoveryDataStore := discovery.NewDataStore(database)
+
+	federationConfig := s.federationConfig(vertexSigningKey)
+	federationTransport, err := federation.NewTransport(federationConfig)
+
+	if err != nil {
+		zap.L().Fatal("error configuring federation transport", zap.Error(err))
+	}
+
+	federationClient := federation.NewClient(federationConfig, &http.Client{
+		Transport: federationTransport,
+	})
+
+	idempotencyManager := idempotency.NewManager(time.Duration(s.config.IdempotencyWindow)*time.Second, time.Duration(s.config.IdempotencyLease)*time.Second, idempotencyDataStore)
+	router.Use(idempotencyManager.Middleware())
+
+	discoveryManager := discovery.NewManager(s.config.Vertex, s.config.FederationScheme, vertexSigningKey)
+	remoteDiscoveryManager := discovery.NewRemoteManager(federationClient, discoveryDataStore)
 
 	adminManager := admin.NewManager(adminDataStore, adminAuthenticator)
+	policyManager := policy.NewManager(s.config.Vertex, policyDataStore)
 	nodeManager := node.NewManager(nodeDataStore)
-	remoteNodeManager := node.NewRemoteManager()
+	remoteNodeManager := node.NewRemoteManager(federationClient, remoteDiscoveryManager, time.Duration(s.config.RemoteNodeCacheTTL)*time.Second, time.Duration(s.config.RemoteNodeMaxStale)*time.Second)
 
-	actorAuthenticator := actor.NewAuthenticator(s.config.Vertex, nodeManager, remoteNodeManager)
-	actorManager := actor.NewManager(actorDataStore, nodeManager, actorAuthenticator)
-	inboxManager := messaging.NewInboxManager(inboxDataStore)
-	outboxManager := messaging.NewOutboxManager(outboxDataStore)
+	actorAuthenticator := actor.NewAuthenticator(s.config.Vertex, s.config.FederationRequireSigned, nodeManager, remoteNodeManager, remoteDiscoveryManager, policyManager)
+	remoteActorManager := actor.NewRemoteManager(federationClient, remoteDiscoveryManager)
+	actorManager := actor.NewManager(s.config.Vertex, actorDataStore, nodeManager, actorAuthenticator, remoteActorManager)
+	envelopeManager := messaging.NewEnvelopeManager(s.config.Vertex, nodeManager, remoteNodeManager)
+	inboxEventManager := messaging.NewInboxEventManager(inboxEventDataStore)
+	webhookManager := messaging.NewWebhookManager(int64(s.config.WebhookMaxAttempts), webhookDataStore, inboxDataStore, messaging.NewWebhookAddressPolicy(s.config.WebhookAllowedHosts))
+	remoteInboxManager := messaging.NewRemoteInboxManager(federationClient, remoteDiscoveryManager)
+	remoteOutboxManager := messaging.NewRemoteOutboxManager(federationClient, remoteDiscoveryManager)
+	deliveryManager := messaging.NewDeliveryManager(s.config.Vertex, int64(s.config.DeliveryMaxAttempts), deliveryDataStore, messageDataStore, remoteInboxManager, remoteOutboxManager, nodeManager, actorAuthenticator, webhookManager, policyManager)
+	receiptManager := messaging.NewReceiptManager(s.config.Vertex, deliveryManager)
+	blobManager := messaging.NewBlobManager(s.config.Vertex, s.config.DataPath, blobDataStore, nodeManager, actorAuthenticator, federationClient, remoteDiscoveryManager)
+	inboxRuleManager := messaging.NewInboxRuleManager(inboxRuleDataStore, inboxDataStore)
+	inboxAccessManager := messaging.NewInboxAccessManager(inboxAccessDataStore, inboxDataStore, messageDataStore)
+	inboxManager := messaging.NewInboxManager(s.config.Vertex, inboxDataStore, messageDataStore, inboxEventManager, envelopeManager, receiptManager, blobManager, inboxRuleManager, inboxAccessManager, webhookManager)
+	threadManager := messaging.NewThreadManager(messageDataStore)
+	searchManager := messaging.NewSearchManager(messageDataStore)
+	retentionManager := messaging.NewRetentionManager(time.Duration(s.config.RetentionInterval)*time.Second, inboxDataStore, outboxDataStore, messageDataStore, deliveryDataStore, inboxEventManager, blobManager)
+	outboxManager := messaging.NewOutboxManager(s.config.Vertex, outboxDataStore, messageDataStore, inboxManager, deliveryManager, envelopeManager, threadManager, blobManager, inboxEventManager)
+	revisionManager := messaging.NewRevisionManager(s.config.Vertex, messageRevisionDataStore, messageDataStore, inboxDataStore, outboxManager, inboxManager, deliveryManager, envelopeManager, inboxEventManager, blobManager)
+	sessionManager := messaging.NewSessionManager(inboxManager, outboxManager)
 
 	health.NewHandler(router).Register()
+	discovery.NewHandler(router, adminAuthenticator, discoveryManager, remoteDiscoveryManager).Register()
 	admin.NewHandler(router, adminAuthenticator, adminManager).Register()
-	node.NewHandler(router, adminAuthenticator, nodeManager).Register()
-	actor.NewHandler(router, actorAuthenticator, actorManager).Register()
-	messaging.NewInboxHandler(router, actorAuthenticator, inboxManager).Register()
-	messaging.NewOutboxHandler(router, actorAuthenticator, outboxManager).Register()
+	policy.NewHandler(router, adminAuthenticator, policyManager).Register()
+	node.NewHandler(router, adminAuthenticator, nodeManager, idempotencyManager).Register()
+	actor.NewHandler(router, actorAuthenticator, actorManager, idempotencyManager).Register()
+	messaging.NewInboxHandler(router, actorAuthenticator, inboxManager, idempotencyManager).Register()
+	messaging.NewOutboxHandler(router, actorAuthenticator, outboxManager, idempotencyManager).Register()
+	messaging.NewDeliveryHandler(router, adminAuthenticator, deliveryManager).Register()
+	messaging.NewReceiptHandler(router, actorAuthenticator, receiptManager).Register()
+	messaging.NewThreadHandler(router, actorAuthenticator, threadManager).Register()
+	messaging.NewRevisionHandler(router, actorAuthenticator, revisionManager, idempotencyManager).Register()
+	messaging.NewSearchHandler(router, actorAuthenticator, searchManager).Register()
+	messaging.NewBlobHandler(router, actorAuthenticator, blobManager).Register()
+	messaging.NewInboxRuleHandler(router, actorAuthenticator, inboxRuleManager).Register()
+	messaging.NewInboxAccessHandler(router, actorAuthenticator, inboxAccessManager).Register()
+	messaging.NewSessionHandler(router, actorAuthenticator, sessionManager, s.config.Vertex, s.config.SessionAllowedOrigins).Register()
+	messaging.NewWebhookHandler(router, actorAuthenticator, webhookManager).Register()
 
-	zap.L().Info("starting vertex", zap.String("host", s.config.Host), zap.String("port", s.config.Port))
-	err = router.Run(fmt.Sprintf("%s:%s", s.config.Host, s.config.Port))
+	s.startWorker(messaging.NewDeliveryWorker(deliveryManager, s.config.DeliveryWorkers).Run)
+	s.startWorker(inboxEventManager.Run)
+	s.startWorker(sessionManager.Run)
+	s.startWorker(retentionManager.Run)
+	s.startWorker(idempotencyManager.Run)
+	s.startWorker(messaging.NewWebhookWorker(webhookManager, s.config.WebhookWorkers).Run)
+	s.startWorker(webhookManager.Run)
+
+	return router
+}
+
+func (s *Server) Close() {
+	s.cancel()
+	s.workers.Wait()
+
+	if s.database == nil {
+		return
+	}
+
+	err := s.database.Close()
 
 	if err != nil {
-		zap.L().Panic("error while starting vertex", zap.Error(err))
+		zap.L().Fatal("error closing sqlite database", zap.Error(err))
+	}
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	zap.L().Info("stopping vertex")
+	s.cancel()
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *Server) federationConfig(signingKey ed25519.PrivateKey) *federation.Config {
+	peerTimeouts := make(map[string]time.Duration, len(s.config.FederationPeerTimeouts))
+
+	for vertex, timeout := range s.config.FederationPeerTimeouts {
+		peerTimeouts[vertex] = time.Duration(timeout) * time.Second
 	}
+
+	return &federation.Config{
+		Vertex:           s.config.Vertex,
+		SigningKey:       signingKey,
+		Scheme:           s.config.FederationScheme,
+		AllowInsecure:    s.config.FederationAllowInsecure,
+		InsecureVertices: s.config.FederationInsecureVertices,
+		CAFile:           s.config.FederationCAFile,
+		ClientCertFile:   s.config.FederationClientCertFile,
+		ClientKeyFile:    s.config.FederationClientKeyFile,
+		Timeout:          time.Duration(s.config.FederationTimeout) * time.Second,
+		PeerTimeouts:     peerTimeouts,
+	}
+}
+
+func (s *Server) startWorker(run func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		run(s.ctx)
+	}()
 }
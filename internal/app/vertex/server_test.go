@@ -0,0 +1,486 @@
+package vertex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	err := os.Chdir("../../..")
+
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+type testVertex struct {
+	t          *testing.T
+	vertex     string
+	baseURL    string
+	adminToken string
+}
+
+func newTestVertex(t *testing.T, options ...func(config *ServerConfig)) *testVertex {
+	httpServer := httptest.NewUnstartedServer(nil)
+	vertex := httpServer.Listener.Addr().String()
+
+	config := &ServerConfig{
+		Vertex:                  vertex,
+		DataPath:                t.TempDir(),
+		StaticPath:              "static",
+		JwtSigningKey:           "secret",
+		FederationScheme:        "http",
+		FederationAllowInsecure: true,
+		DeliveryWorkers:         1,
+		DeliveryMaxAttempts:     3,
+		RetentionInterval:       300,
+		IdempotencyWindow:       86400,
+		IdempotencyLease:        30,
+		WebhookWorkers:          1,
+		WebhookMaxAttempts:      1,
+		RemoteNodeCacheTTL:      300,
+		RemoteNodeMaxStale:      0,
+		FederationTimeout:       5,
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	server := NewServer(config)
+
+	httpServer.Config.Handler = server.Handler()
+	httpServer.Start()
+
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Close()
+	})
+
+	return &testVertex{t: t, vertex: vertex, baseURL: httpServer.URL}
+}
+
+func (v *testVertex) call(method string, path string, token string, request interface{}, response interface{}) int {
+	v.t.Helper()
+
+	var body bytes.Buffer
+
+	if request != nil {
+		err := json.NewEncoder(&body).Encode(request)
+
+		if err != nil {
+			v.t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, v.baseURL+path, &body)
+
+	if err != nil {
+		v.t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		v.t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	if response != nil {
+		_ = json.NewDecoder(resp.Body).Decode(response)
+	}
+
+	return resp.StatusCode
+}
+
+func (v *testVertex) expect(status int, method string, path string, token string, request interface{}, response interface{}) {
+	v.t.Helper()
+
+	got := v.call(method, path, token, request, response)
+
+	if got != status {
+		v.t.Fatalf("%s %s on %s: expected status %d, got %d", method, path, v.vertex, status, got)
+	}
+}
+
+func (v *testVertex) admin() string {
+	v.t.Helper()
+
+	if v.adminToken != "" {
+		return v.adminToken
+	}
+
+	var admin struct {
+		Token string `json:"token"`
+	}
+
+	credentials := map[string]string{"identifier": "root", "password": "password"}
+	v.expect(http.StatusCreated, http.MethodPost, "/api/v1/admins/init", "", credentials, nil)
+	v.expect(http.StatusOK, http.MethodPost, "/api/v1/admins/token", "", credentials, &admin)
+
+	v.adminToken = admin.Token
+
+	return v.adminToken
+}
+
+func (v *testVertex) actorToken(nodeIdentifier string, actorIdentifier string) string {
+	v.t.Helper()
+
+	v.expect(http.StatusCreated, http.MethodPost, "/api/v1/nodes", v.admin(), map[string]string{
+		"identifier":   nodeIdentifier,
+		"display_name": nodeIdentifier,
+	}, nil)
+
+	v.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/api/v1/nodes/%s/actors/signup", nodeIdentifier), "", map[string]string{
+		"identifier":   actorIdentifier,
+		"password":     "password",
+		"type":         "person",
+		"display_name": actorIdentifier,
+	}, nil)
+
+	return v.remoteActorToken(nodeIdentifier, actorIdentifier, "")
+}
+
+func (v *testVertex) remoteActorToken(nodeIdentifier string, actorIdentifier string, targetNodeAddress string) string {
+	v.t.Helper()
+
+	var actor struct {
+		Token string `json:"token"`
+	}
+
+	v.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/api/v1/nodes/%s/actors/token", nodeIdentifier), "", map[string]string{
+		"identifier":          actorIdentifier,
+		"password":            "password",
+		"target_node_address": targetNodeAddress,
+	}, &actor)
+
+	return actor.Token
+}
+
+func TestFederatedDelivery(t *testing.T) {
+	sender := newTestVertex(t)
+	recipient := newTestVertex(t)
+
+	senderToken := sender.actorToken("sender-node", "alice")
+	recipientToken := recipient.actorToken("recipient-node", "bob")
+
+	sender.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/outboxes", senderToken, map[string]string{
+		"identifier":   "sent",
+		"display_name": "Sent",
+	}, nil)
+
+	recipient.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/inboxes", recipientToken, map[string]string{
+		"identifier":   "main",
+		"display_name": "Main",
+	}, nil)
+
+	sender.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/outboxes/sent/messages", senderToken, map[string]string{
+		"recipient_address": fmt.Sprintf("%s/recipient-node/bob/main", recipient.vertex),
+		"content_type":      "text/plain",
+		"content":           "hello across vertices",
+	}, nil)
+
+	deadline := time.Now().Add(10 * time.Second)
+
+	for {
+		var messages []struct {
+			SenderAddress string `json:"sender_address"`
+			Content       string `json:"content"`
+		}
+
+		recipient.expect(http.StatusOK, http.MethodGet, "/api/v1/messaging/inboxes/main/messages", recipientToken, nil, &messages)
+
+		if len(messages) == 1 {
+			if messages[0].Content != "hello across vertices" {
+				t.Fatalf("unexpected content %q", messages[0].Content)
+			}
+
+			if messages[0].SenderAddress != fmt.Sprintf("%s/sender-node/alice", sender.vertex) {
+				t.Fatalf("unexpected sender %q", messages[0].SenderAddress)
+			}
+
+			var key struct {
+				PublicKey string `json:"public_key"`
+			}
+
+			var document struct {
+				PublicKey string `json:"public_key"`
+			}
+
+			keyPath := fmt.Sprintf("/api/v1/federation/vertices/%s/key", recipient.vertex)
+
+			sender.expect(http.StatusOK, http.MethodGet, keyPath, sender.admin(), nil, &key)
+			recipient.expect(http.StatusOK, http.MethodGet, "/.well-known/evernet", "", nil, &document)
+
+			if key.PublicKey == "" || key.PublicKey != document.PublicKey {
+				t.Fatalf("expected pinned key %q, got %q", document.PublicKey, key.PublicKey)
+			}
+
+			sender.expect(http.StatusOK, http.MethodDelete, keyPath, sender.admin(), nil, nil)
+			sender.expect(http.StatusNotFound, http.MethodGet, keyPath, sender.admin(), nil, nil)
+
+			unsignedToken := sender.remoteActorToken("sender-node", "alice", fmt.Sprintf("%s/recipient-node", recipient.vertex))
+
+			recipient.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/messaging/deliveries", unsignedToken, map[string]interface{}{}, nil)
+
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("message was not delivered to %s, inbox has %d messages", recipient.vertex, len(messages))
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestFederatedFanOutAcrossNodes(t *testing.T) {
+	sender := newTestVertex(t)
+	recipient := newTestVertex(t)
+
+	senderToken := sender.actorToken("sender-node", "alice")
+	recipientTokens := map[string]string{
+		"first-node/bob/bob-inbox":      recipient.actorToken("first-node", "bob"),
+		"second-node/carol/carol-inbox": recipient.actorToken("second-node", "carol"),
+	}
+
+	sender.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/outboxes", senderToken, map[string]string{
+		"identifier":   "sent",
+		"display_name": "Sent",
+	}, nil)
+
+	var recipientAddresses []string
+
+	for inboxAddress, token := range recipientTokens {
+		recipient.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/inboxes", token, map[string]string{
+			"identifier":   path.Base(inboxAddress),
+			"display_name": "Main",
+		}, nil)
+
+		recipientAddresses = append(recipientAddresses, fmt.Sprintf("%s/%s", recipient.vertex, inboxAddress))
+	}
+
+	sender.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/messaging/outboxes/sent/messages", senderToken, map[string]interface{}{
+		"recipient_addresses":   recipientAddresses,
+		"content_type":          "text/plain",
+		"content":               "c2VhbGVk",
+		"encryption":            "x25519",
+		"encryption_public_key": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+	}, nil)
+
+	sender.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/outboxes/sent/messages", senderToken, map[string]interface{}{
+		"recipient_addresses": recipientAddresses,
+		"content_type":        "text/plain",
+		"content":             "hello to both nodes",
+	}, nil)
+
+	deadline := time.Now().Add(10 * time.Second)
+
+	for inboxAddress, token := range recipientTokens {
+		for {
+			var messages []struct {
+				Content string `json:"content"`
+			}
+
+			recipient.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/messaging/inboxes/%s/messages", path.Base(inboxAddress)), token, nil, &messages)
+
+			if len(messages) == 1 && messages[0].Content == "hello to both nodes" {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				t.Fatalf("message was not delivered to %s, inbox has %d messages", inboxAddress, len(messages))
+			}
+
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	vertexToken := sender.remoteActorToken("sender-node", "alice", recipient.vertex)
+
+	recipient.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/messaging/deliveries", vertexToken, map[string]interface{}{}, nil)
+}
+
+func TestCursorPagination(t *testing.T) {
+	vertex := newTestVertex(t)
+	token := vertex.actorToken("node", "alice")
+
+	vertex.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/outboxes", token, map[string]string{
+		"identifier":   "sent",
+		"display_name": "Sent",
+	}, nil)
+
+	vertex.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/inboxes", token, map[string]string{
+		"identifier":   "main",
+		"display_name": "Main",
+	}, nil)
+
+	for i := 0; i < 5; i++ {
+		vertex.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/outboxes/sent/messages", token, map[string]string{
+			"recipient_address": fmt.Sprintf("%s/node/alice/main", vertex.vertex),
+			"content_type":      "text/plain",
+			"content":           fmt.Sprintf("message %d", i),
+		}, nil)
+	}
+
+	paginate := func(path string) {
+		t.Helper()
+
+		seen := make(map[string]bool)
+		cursor := ""
+
+		for page := 0; ; page++ {
+			if page > 5 {
+				t.Fatalf("%s: cursor pagination did not terminate", path)
+			}
+
+			var response struct {
+				Items []struct {
+					Identifier string `json:"identifier"`
+				} `json:"items"`
+				NextCursor string `json:"next_cursor"`
+			}
+
+			vertex.expect(http.StatusOK, http.MethodGet, path+"size=2&cursor="+cursor, token, nil, &response)
+
+			for _, item := range response.Items {
+				if seen[item.Identifier] {
+					t.Fatalf("%s: message %s was returned twice", path, item.Identifier)
+				}
+
+				seen[item.Identifier] = true
+			}
+
+			if response.NextCursor == "" {
+				break
+			}
+
+			cursor = response.NextCursor
+		}
+
+		if len(seen) != 5 {
+			t.Fatalf("%s: expected 5 messages across pages, got %d", path, len(seen))
+		}
+	}
+
+	paginate("/api/v1/messaging/inboxes/main/messages?")
+	paginate("/api/v1/messaging/search?q=message&")
+
+	vertex.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/messaging/inboxes/main/messages?cursor=invalid!", token, nil, nil)
+}
+
+func TestRequireSignedFederatedRequests(t *testing.T) {
+	requireSigned := func(config *ServerConfig) {
+		config.FederationRequireSigned = true
+	}
+
+	sender := newTestVertex(t, requireSigned)
+	recipient := newTestVertex(t, requireSigned)
+
+	senderToken := sender.actorToken("sender-node", "alice")
+	recipientToken := recipient.actorToken("recipient-node", "bob")
+
+	recipient.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/inboxes", recipientToken, map[string]string{
+		"identifier":   "main",
+		"display_name": "Main",
+	}, nil)
+
+	unsignedToken := sender.remoteActorToken("sender-node", "alice", fmt.Sprintf("%s/recipient-node", recipient.vertex))
+
+	recipient.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/messaging/deliveries", unsignedToken, map[string]interface{}{}, nil)
+
+	sender.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/outboxes", senderToken, map[string]string{
+		"identifier":   "sent",
+		"display_name": "Sent",
+	}, nil)
+
+	sender.expect(http.StatusCreated, http.MethodPost, "/api/v1/messaging/outboxes/sent/messages", senderToken, map[string]string{
+		"recipient_address": fmt.Sprintf("%s/recipient-node/bob/main", recipient.vertex),
+		"content_type":      "text/plain",
+		"content":           "signed hello",
+	}, nil)
+
+	deadline := time.Now().Add(10 * time.Second)
+
+	for {
+		var messages []struct {
+			Content string `json:"content"`
+		}
+
+		recipient.expect(http.StatusOK, http.MethodGet, "/api/v1/messaging/inboxes/main/messages", recipientToken, nil, &messages)
+
+		if len(messages) == 1 && messages[0].Content == "signed hello" {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("signed message was not delivered, inbox has %d messages", len(messages))
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestSessionOrigin(t *testing.T) {
+	vertex := newTestVertex(t, func(config *ServerConfig) {
+		config.SessionAllowedOrigins = []string{"https://App.Example"}
+	})
+
+	token := vertex.actorToken("node", "alice")
+	location := strings.Replace(vertex.baseURL, "http://", "ws://", 1) + "/api/v1/messaging/sessions"
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"http://" + vertex.vertex, true},
+		{"https://app.example", true},
+		{"https://attacker.example", false},
+		{"https://app.example.attacker.example", false},
+	}
+
+	for _, test := range tests {
+		config, err := websocket.NewConfig(location, test.origin)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config.Header.Set("Authorization", "Bearer "+token)
+
+		conn, err := websocket.DialConfig(config)
+
+		if test.allowed && err != nil {
+			t.Errorf("%s: expected session to open, got %v", test.origin, err)
+		}
+
+		if !test.allowed && err == nil {
+			t.Errorf("%s: expected session to be refused", test.origin)
+		}
+
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Cursor struct {
+	CreatedAt  int64
+	Identifier string
+}
+
+func (c *Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", c.CreatedAt, c.Identifier)))
+}
+
+func ParseCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	components := strings.SplitN(string(data), ":", 2)
+
+	if len(components) != 2 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	createdAt, err := strconv.ParseInt(components[0], 10, 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &Cursor{CreatedAt: createdAt, Identifier: components[1]}, nil
+}
+
+type Pagination struct {
+	Page   int64
+	Size   int64
+	Keyset bool
+	Cursor *Cursor
+}
+
+func Paginate(c *gin.Context) (*Pagination, error) {
+	page, size := Page(c)
+	value, keyset := c.GetQuery("cursor")
+
+	pagination := &Pagination{Page: page, Size: size, Keyset: keyset}
+
+	if keyset && value != "" {
+		cursor, err := ParseCursor(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pagination.Cursor = cursor
+	}
+
+	return pagination, nil
+}
+
+func (p *Pagination) Offset() int64 {
+	if p.Keyset {
+		return 0
+	}
+
+	return p.Page * p.Size
+}
+
+func (p *Pagination) Bounds(descending bool) (int64, string) {
+	if p.Keyset && p.Cursor != nil {
+		return p.Cursor.CreatedAt, p.Cursor.Identifier
+	}
+
+	if descending {
+		return math.MaxInt64, ""
+	}
+
+	return math.MinInt64, ""
+}
+
+type CursorResponse[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func Paginated[T any](c *gin.Context, httpStatusCode int, pagination *Pagination, items []T, cursor func(T) *Cursor) {
+	if !pagination.Keyset {
+		c.Header("Deprecation", "true")
+		c.JSON(httpStatusCode, items)
+		return
+	}
+
+	response := &CursorResponse[T]{Items: items}
+
+	if response.Items == nil {
+		response.Items = make([]T, 0)
+	}
+
+	if len(items) > 0 && int64(len(items)) == pagination.Size {
+		response.NextCursor = cursor(items[len(items)-1]).String()
+	}
+
+	c.JSON(httpStatusCode, response)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursors := []*Cursor{
+		{CreatedAt: 1792304254606630575, Identifier: "3d66fcb06e0d507455e3ccbe0ec0af60"},
+		{CreatedAt: -5, Identifier: "with:colons:inside"},
+		{CreatedAt: 0, Identifier: ""},
+	}
+
+	for _, cursor := range cursors {
+		parsed, err := ParseCursor(cursor.String())
+
+		if err != nil {
+			t.Fatalf("ParseCursor(%q): %v", cursor.String(), err)
+		}
+
+		if *parsed != *cursor {
+			t.Fatalf("expected %+v, got %+v", cursor, parsed)
+		}
+	}
+}
+
+func TestParseCursorRejectsInvalidValues(t *testing.T) {
+	values := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("no-separator")),
+		base64.RawURLEncoding.EncodeToString([]byte("abc:identifier")),
+		base64.StdEncoding.EncodeToString([]byte("1:identifier?")),
+	}
+
+	for _, value := range values {
+		_, err := ParseCursor(value)
+
+		if err == nil {
+			t.Errorf("expected ParseCursor(%q) to fail", value)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cursor := &Cursor{CreatedAt: 42, Identifier: "message"}
+
+	tests := []struct {
+		query      string
+		keyset     bool
+		cursor     *Cursor
+		offset     int64
+		lowerBound int64
+		upperBound int64
+	}{
+		{query: "page=2&size=10", offset: 20, lowerBound: math.MinInt64, upperBound: math.MaxInt64},
+		{query: "cursor=&page=2&size=10", keyset: true, offset: 0, lowerBound: math.MinInt64, upperBound: math.MaxInt64},
+		{query: "cursor=" + cursor.String() + "&size=10", keyset: true, cursor: cursor, offset: 0, lowerBound: 42, upperBound: 42},
+	}
+
+	for _, test := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/?"+test.query, nil)
+
+		pagination, err := Paginate(c)
+
+		if err != nil {
+			t.Fatalf("%s: %v", test.query, err)
+		}
+
+		if pagination.Keyset != test.keyset || pagination.Size != 10 || pagination.Offset() != test.offset {
+			t.Fatalf("%s: unexpected pagination %+v with offset %d", test.query, pagination, pagination.Offset())
+		}
+
+		if (pagination.Cursor == nil) != (test.cursor == nil) || (test.cursor != nil && *pagination.Cursor != *test.cursor) {
+			t.Fatalf("%s: expected cursor %+v, got %+v", test.query, test.cursor, pagination.Cursor)
+		}
+
+		if lower, _ := pagination.Bounds(false); lower != test.lowerBound {
+			t.Fatalf("%s: expected ascending bound %d, got %d", test.query, test.lowerBound, lower)
+		}
+
+		if upper, _ := pagination.Bounds(true); upper != test.upperBound {
+			t.Fatalf("%s: expected descending bound %d, got %d", test.query, test.upperBound, upper)
+		}
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?cursor=invalid!", nil)
+
+	_, err := Paginate(c)
+
+	if err == nil {
+		t.Fatal("expected an invalid cursor to be rejected")
+	}
+}
+
+func TestPaginated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cursor := func(item int) *Cursor {
+		return &Cursor{CreatedAt: int64(item), Identifier: "item"}
+	}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	Paginated(c, http.StatusOK, &Pagination{Size: 2, Keyset: true}, []int{1, 2}, cursor)
+
+	var response CursorResponse[int]
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Items) != 2 || response.NextCursor != cursor(2).String() {
+		t.Fatalf("unexpected full page response %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(recorder)
+
+	Paginated(c, http.StatusOK, &Pagination{Size: 2, Keyset: true}, []int{3}, cursor)
+
+	if recorder.Body.String() != `{"items":[3]}` {
+		t.Fatalf("unexpected last page response %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(recorder)
+
+	Paginated[int](c, http.StatusOK, &Pagination{Size: 2, Keyset: true}, nil, cursor)
+
+	if recorder.Body.String() != `{"items":[]}` {
+		t.Fatalf("unexpected empty page response %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(recorder)
+
+	Paginated(c, http.StatusOK, &Pagination{Size: 2}, []int{1, 2}, cursor)
+
+	if recorder.Body.String() != `[1,2]` || recorder.Header().Get("Deprecation") != "true" {
+		t.Fatalf("unexpected offset response %s", recorder.Body.String())
+	}
+}
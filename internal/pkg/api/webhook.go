@@ -0,0 +1,7 @@
+package api
+
+const (
+	WebhookEventHeader     = "Evernet-Event"
+	WebhookDeliveryHeader  = "Evernet-Delivery"
+	WebhookSignatureHeader = "Evernet-Signature"
+)
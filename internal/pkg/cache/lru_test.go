@@ -0,0 +1,31 @@
+package cache
+
+import "testing"
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewLRU[string, int](2)
+
+	lru.Put("a", 1)
+	lru.Put("b", 2)
+	lru.Get("a")
+	lru.Put("c", 3)
+
+	if _, ok := lru.Get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+
+	if value, ok := lru.Get("a"); !ok || value != 1 {
+		t.Fatalf("expected a to be 1, got %d (%t)", value, ok)
+	}
+
+	if value, ok := lru.Get("c"); !ok || value != 3 {
+		t.Fatalf("expected c to be 3, got %d (%t)", value, ok)
+	}
+
+	lru.Put("a", 4)
+	lru.Remove("c")
+
+	if value, _ := lru.Get("a"); value != 4 || lru.Len() != 1 {
+		t.Fatalf("expected only a=4, got a=%d with %d entries", value, lru.Len())
+	}
+}
@@ -1,6 +1,10 @@
 package env
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+)
 
 func GetOrDefault(key string, def string) string {
 	val := os.Getenv(key)
@@ -11,3 +15,71 @@ func GetOrDefault(key string, def string) string {
 
 	return val
 }
+
+func GetIntOrDefault(key string, def int) int {
+	val, err := strconv.Atoi(os.Getenv(key))
+
+	if err != nil {
+		return def
+	}
+
+	return val
+}
+
+func GetBoolOrDefault(key string, def bool) bool {
+	val, err := strconv.ParseBool(os.Getenv(key))
+
+	if err != nil {
+		return def
+	}
+
+	return val
+}
+
+func GetListOrDefault(key string, def []string) []string {
+	val := os.Getenv(key)
+
+	if val == "" {
+		return def
+	}
+
+	var list []string
+
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+func GetIntMapOrDefault(key string, def map[string]int) map[string]int {
+	val := os.Getenv(key)
+
+	if val == "" {
+		return def
+	}
+
+	values := make(map[string]int)
+
+	for _, item := range strings.Split(val, ",") {
+		name, number, ok := strings.Cut(strings.TrimSpace(item), "=")
+
+		if !ok {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(strings.TrimSpace(number))
+
+		if err != nil {
+			continue
+		}
+
+		values[strings.TrimSpace(name)] = parsed
+	}
+
+	return values
+}
@@ -0,0 +1,76 @@
+package federation
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func CacheLifetime(header http.Header, defaultTTL time.Duration, maxTTL time.Duration) (time.Duration, bool) {
+	maxAge, sharedMaxAge := int64(-1), int64(-1)
+
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+
+		switch name {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			return 0, true
+		case "max-age":
+			maxAge = parseSeconds(value)
+		case "s-maxage":
+			sharedMaxAge = parseSeconds(value)
+		}
+	}
+
+	var ttl time.Duration
+
+	switch {
+	case sharedMaxAge >= 0:
+		ttl = time.Duration(sharedMaxAge) * time.Second
+	case maxAge >= 0:
+		ttl = time.Duration(maxAge) * time.Second
+	case header.Get("Expires") != "":
+		expires, err := http.ParseTime(header.Get("Expires"))
+
+		if err != nil {
+			return 0, true
+		}
+
+		date, err := http.ParseTime(header.Get("Date"))
+
+		if err != nil {
+			date = time.Now()
+		}
+
+		ttl = expires.Sub(date)
+	default:
+		ttl = defaultTTL
+	}
+
+	if age := parseSeconds(header.Get("Age")); age > 0 {
+		ttl -= time.Duration(age) * time.Second
+	}
+
+	if ttl < 0 {
+		return 0, true
+	}
+
+	if ttl > maxTTL {
+		return maxTTL, true
+	}
+
+	return ttl, true
+}
+
+func parseSeconds(value string) int64 {
+	seconds, err := strconv.ParseInt(strings.Trim(value, "\""), 10, 64)
+
+	if err != nil || seconds < 0 {
+		return -1
+	}
+
+	return seconds
+}
@@ -0,0 +1,49 @@
+package federation
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheLifetime(t *testing.T) {
+	date := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		header    map[string]string
+		ttl       time.Duration
+		cacheable bool
+	}{
+		{"default", nil, time.Hour, true},
+		{"no-store", map[string]string{"Cache-Control": "max-age=60, no-store"}, 0, false},
+		{"no-cache", map[string]string{"Cache-Control": "no-cache"}, 0, true},
+		{"max-age", map[string]string{"Cache-Control": "public, max-age=60"}, time.Minute, true},
+		{"quoted max-age", map[string]string{"Cache-Control": `max-age="60"`}, time.Minute, true},
+		{"s-maxage precedence", map[string]string{"Cache-Control": "max-age=60, s-maxage=120"}, 2 * time.Minute, true},
+		{"case insensitive", map[string]string{"Cache-Control": "Max-Age=60"}, time.Minute, true},
+		{"invalid max-age", map[string]string{"Cache-Control": "max-age=soon"}, time.Hour, true},
+		{"negative max-age", map[string]string{"Cache-Control": "max-age=-5"}, time.Hour, true},
+		{"age", map[string]string{"Cache-Control": "max-age=60", "Age": "20"}, 40 * time.Second, true},
+		{"age exceeds max-age", map[string]string{"Cache-Control": "max-age=60", "Age": "90"}, 0, true},
+		{"expires", map[string]string{"Expires": date.Add(10 * time.Minute).Format(http.TimeFormat), "Date": date.Format(http.TimeFormat)}, 10 * time.Minute, true},
+		{"expired", map[string]string{"Expires": date.Add(-time.Minute).Format(http.TimeFormat), "Date": date.Format(http.TimeFormat)}, 0, true},
+		{"invalid expires", map[string]string{"Expires": "0"}, 0, true},
+		{"max-age over expires", map[string]string{"Cache-Control": "max-age=60", "Expires": date.Format(http.TimeFormat), "Date": date.Format(http.TimeFormat)}, time.Minute, true},
+		{"clamped", map[string]string{"Cache-Control": "max-age=31536000"}, 24 * time.Hour, true},
+	}
+
+	for _, test := range tests {
+		header := make(http.Header)
+
+		for name, value := range test.header {
+			header.Set(name, value)
+		}
+
+		ttl, cacheable := CacheLifetime(header, time.Hour, 24*time.Hour)
+
+		if ttl != test.ttl || cacheable != test.cacheable {
+			t.Errorf("%s: expected (%s, %t), got (%s, %t)", test.name, test.ttl, test.cacheable, ttl, cacheable)
+		}
+	}
+}
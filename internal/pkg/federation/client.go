@@ -0,0 +1,235 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/evernetproto/evernet/internal/pkg/api"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type Client struct {
+	config     *Config
+	httpClient *http.Client
+}
+
+func NewClient(config *Config, httpClient *http.Client) *Client {
+	return &Client{config: config, httpClient: httpClient}
+}
+
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected response status: %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("unexpected response status: %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) URL(vertex string, path string) string {
+	return fmt.Sprintf("%s://%s%s", c.config.Scheme, vertex, path)
+}
+
+func (c *Client) timeout(vertex string) time.Duration {
+	if timeout, ok := c.config.PeerTimeouts[vertex]; ok {
+		return timeout
+	}
+
+	return c.config.Timeout
+}
+
+func (c *Client) allow(req *http.Request) error {
+	if slices.Contains(c.config.InsecureVertices, req.URL.Host) {
+		req.URL.Scheme = "http"
+		return nil
+	}
+
+	switch req.URL.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if c.config.Scheme == "http" && c.config.AllowInsecure {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("refusing %s federation request to %s", req.URL.Scheme, req.URL.Host)
+}
+
+func (c *Client) prepare(req *http.Request, token string) error {
+	err := c.allow(req)
+
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if c.config.SigningKey == nil {
+		return nil
+	}
+
+	var body []byte
+
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+
+		body, err = io.ReadAll(reader)
+
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	return SignRequest(req, body, c.config.Vertex, c.config.SigningKey)
+}
+
+func (c *Client) Get(ctx context.Context, url string, token string, response interface{}) error {
+	_, err := c.GetWithHeader(ctx, url, token, response)
+	return err
+}
+
+func (c *Client) GetWithHeader(ctx context.Context, url string, token string, response interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	return c.exchange(req, token, response)
+}
+
+func (c *Client) Post(ctx context.Context, url string, token string, request interface{}, response interface{}) error {
+	return c.PostIdempotent(ctx, url, token, "", request, response)
+}
+
+func (c *Client) PostIdempotent(ctx context.Context, url string, token string, idempotencyKey string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if idempotencyKey != "" {
+		req.Header.Set(api.IdempotencyKeyHeader, idempotencyKey)
+	}
+
+	return c.do(req, token, response)
+}
+
+func (c *Client) Download(ctx context.Context, url string, token string, writer io.Writer) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	err = c.prepare(req, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to make %s request: %w", req.Method, err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			zap.L().Error("failed to close response body", zap.Error(err))
+		}
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errorResponse api.ErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errorResponse)
+
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: errorResponse.Message}
+	}
+
+	_, err = io.Copy(writer, resp.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return resp.Header, nil
+}
+
+func (c *Client) do(req *http.Request, token string, response interface{}) error {
+	_, err := c.exchange(req, token, response)
+	return err
+}
+
+func (c *Client) exchange(req *http.Request, token string, response interface{}) (http.Header, error) {
+	err := c.prepare(req, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.timeout(req.URL.Host))
+	defer cancel()
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to make %s request: %w", req.Method, err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			zap.L().Error("failed to close response body", zap.Error(err))
+		}
+	}(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errorResponse api.ErrorResponse
+		_ = json.Unmarshal(body, &errorResponse)
+
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: errorResponse.Message}
+	}
+
+	if response == nil {
+		return resp.Header, nil
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	return resp.Header, nil
+}
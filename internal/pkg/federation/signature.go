@@ -0,0 +1,287 @@
+package federation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader      = "Signature"
+	SignatureInputHeader = "Signature-Input"
+	ContentDigestHeader  = "Content-Digest"
+	SignatureLabel       = "evernet"
+	SignatureAlgorithm   = "ed25519"
+	SignatureMaxAge      = 5 * time.Minute
+)
+
+var signatureComponents = []string{"@method", "@authority", "@path", "@query"}
+
+type SignatureKeyFunc func(keyID string) (ed25519.PublicKey, error)
+
+func IsSigned(req *http.Request) bool {
+	return req.Header.Get(SignatureInputHeader) != "" || req.Header.Get(SignatureHeader) != ""
+}
+
+func SignRequest(req *http.Request, body []byte, keyID string, privateKey ed25519.PrivateKey) error {
+	components := slices.Clone(signatureComponents)
+
+	if req.Header.Get("Authorization") != "" {
+		components = append(components, "authorization")
+	}
+
+	if len(body) > 0 {
+		req.Header.Set(ContentDigestHeader, contentDigest(body))
+		components = append(components, "content-digest")
+	}
+
+	params := signatureParams(components, time.Now().Unix(), keyID)
+	base, err := signatureBase(req, components, params)
+
+	if err != nil {
+		return err
+	}
+
+	signature := ed25519.Sign(privateKey, []byte(base))
+
+	req.Header.Set(SignatureInputHeader, fmt.Sprintf("%s=%s", SignatureLabel, params))
+	req.Header.Set(SignatureHeader, fmt.Sprintf("%s=:%s:", SignatureLabel, base64.StdEncoding.EncodeToString(signature)))
+
+	return nil
+}
+
+func VerifyRequest(req *http.Request, expectedAuthority string, keyFunc SignatureKeyFunc) (string, error) {
+	if !strings.EqualFold(authority(req), expectedAuthority) {
+		return "", fmt.Errorf("request signature is not intended for %s", expectedAuthority)
+	}
+
+	params, ok := dictionaryMember(req.Header.Get(SignatureInputHeader), SignatureLabel)
+
+	if !ok {
+		return "", fmt.Errorf("missing request signature")
+	}
+
+	components, created, keyID, err := parseSignatureParams(params)
+
+	if err != nil {
+		return "", err
+	}
+
+	age := time.Since(time.Unix(created, 0))
+
+	if age > SignatureMaxAge || age < -SignatureMaxAge {
+		return "", fmt.Errorf("request signature has expired")
+	}
+
+	for _, component := range signatureComponents {
+		if !slices.Contains(components, component) {
+			return "", fmt.Errorf("request signature does not cover %s", component)
+		}
+	}
+
+	if req.Header.Get("Authorization") != "" && !slices.Contains(components, "authorization") {
+		return "", fmt.Errorf("request signature does not cover authorization")
+	}
+
+	body, err := readBody(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(body) > 0 {
+		if !slices.Contains(components, "content-digest") {
+			return "", fmt.Errorf("request signature does not cover content-digest")
+		}
+
+		if req.Header.Get(ContentDigestHeader) != contentDigest(body) {
+			return "", fmt.Errorf("content digest does not match request body")
+		}
+	}
+
+	value, ok := dictionaryMember(req.Header.Get(SignatureHeader), SignatureLabel)
+
+	if !ok || !strings.HasPrefix(value, ":") || !strings.HasSuffix(value, ":") || len(value) < 2 {
+		return "", fmt.Errorf("missing request signature")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+
+	if err != nil {
+		return "", fmt.Errorf("invalid request signature")
+	}
+
+	publicKey, err := keyFunc(keyID)
+
+	if err != nil {
+		return "", err
+	}
+
+	base, err := signatureBase(req, components, params)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, []byte(base), signature) {
+		return "", fmt.Errorf("invalid request signature")
+	}
+
+	return keyID, nil
+}
+
+func signatureParams(components []string, created int64, keyID string) string {
+	quoted := make([]string, len(components))
+
+	for i, component := range components {
+		quoted[i] = strconv.Quote(component)
+	}
+
+	return fmt.Sprintf("(%s);created=%d;keyid=%s;alg=%s", strings.Join(quoted, " "), created, strconv.Quote(keyID), strconv.Quote(SignatureAlgorithm))
+}
+
+func parseSignatureParams(params string) ([]string, int64, string, error) {
+	end := strings.Index(params, ")")
+
+	if !strings.HasPrefix(params, "(") || end < 0 {
+		return nil, 0, "", fmt.Errorf("invalid signature input")
+	}
+
+	var components []string
+
+	for _, item := range strings.Fields(params[1:end]) {
+		component, err := strconv.Unquote(item)
+
+		if err != nil {
+			return nil, 0, "", fmt.Errorf("invalid signature input")
+		}
+
+		components = append(components, component)
+	}
+
+	var created int64
+	var keyID, algorithm string
+
+	for _, param := range strings.Split(params[end+1:], ";") {
+		name, value, ok := strings.Cut(param, "=")
+
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "created":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+
+			if err != nil {
+				return nil, 0, "", fmt.Errorf("invalid signature creation time")
+			}
+
+			created = parsed
+		case "keyid":
+			keyID, _ = strconv.Unquote(value)
+		case "alg":
+			algorithm, _ = strconv.Unquote(value)
+		}
+	}
+
+	if keyID == "" || created == 0 {
+		return nil, 0, "", fmt.Errorf("invalid signature input")
+	}
+
+	if algorithm != "" && algorithm != SignatureAlgorithm {
+		return nil, 0, "", fmt.Errorf("unsupported signature algorithm %s", algorithm)
+	}
+
+	return components, created, keyID, nil
+}
+
+func signatureBase(req *http.Request, components []string, params string) (string, error) {
+	var builder strings.Builder
+
+	for _, component := range components {
+		var value string
+
+		switch component {
+		case "@method":
+			value = req.Method
+		case "@authority":
+			value = strings.ToLower(authority(req))
+		case "@path":
+			value = req.URL.EscapedPath()
+
+			if value == "" {
+				value = "/"
+			}
+		case "@query":
+			value = "?" + req.URL.RawQuery
+		default:
+			if strings.HasPrefix(component, "@") {
+				return "", fmt.Errorf("unsupported signature component %s", component)
+			}
+
+			values := req.Header.Values(component)
+
+			if len(values) == 0 {
+				return "", fmt.Errorf("missing signed header %s", component)
+			}
+
+			value = strings.Join(values, ", ")
+		}
+
+		builder.WriteString(fmt.Sprintf("%q: %s\n", component, strings.TrimSpace(value)))
+	}
+
+	builder.WriteString(fmt.Sprintf("%q: %s", "@signature-params", params))
+
+	return builder.String(), nil
+}
+
+func authority(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+
+	return req.URL.Host
+}
+
+func dictionaryMember(header string, label string) (string, bool) {
+	for _, member := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+
+		if ok && name == label {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func contentDigest(body []byte) string {
+	digest := sha256.Sum256(body)
+	return fmt.Sprintf("sha-256=:%s:", base64.StdEncoding.EncodeToString(digest[:]))
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
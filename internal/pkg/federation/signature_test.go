@@ -0,0 +1,159 @@
+package federation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSignedRequest(t *testing.T, privateKey ed25519.PrivateKey, body string, token string) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "https://vertex.example/api/v1/messaging/deliveries?batch=1", strings.NewReader(body))
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	err := SignRequest(req, []byte(body), "sender.example", privateKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return req
+}
+
+func TestSignAndVerifyRequest(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFunc := func(keyID string) (ed25519.PublicKey, error) {
+		if keyID != "sender.example" {
+			return nil, fmt.Errorf("unknown key %s", keyID)
+		}
+
+		return publicKey, nil
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		token  string
+		tamper func(req *http.Request)
+		valid  bool
+	}{
+		{"signed with token and body", `{"content":"hello"}`, "token", nil, true},
+		{"signed without token", "", "", nil, true},
+		{"body changed", `{"content":"hello"}`, "token", func(req *http.Request) {
+			req.Body = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"content":"bye"}`)).Body
+		}, false},
+		{"token replaced", `{}`, "token", func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer other")
+		}, false},
+		{"token added", "", "", func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer token")
+		}, false},
+		{"method changed", `{}`, "", func(req *http.Request) {
+			req.Method = http.MethodPut
+		}, false},
+		{"path changed", `{}`, "", func(req *http.Request) {
+			req.URL.Path = "/api/v1/messaging/receipts"
+		}, false},
+		{"query changed", `{}`, "", func(req *http.Request) {
+			req.URL.RawQuery = "batch=2"
+		}, false},
+		{"authority changed", `{}`, "", func(req *http.Request) {
+			req.Host = "other.example"
+		}, false},
+		{"signature removed", `{}`, "", func(req *http.Request) {
+			req.Header.Del(SignatureHeader)
+		}, false},
+		{"unknown key", `{}`, "", func(req *http.Request) {
+			req.Header.Set(SignatureInputHeader, strings.Replace(req.Header.Get(SignatureInputHeader), "sender.example", "attacker.example", 1))
+		}, false},
+		{"expired", `{}`, "", func(req *http.Request) {
+			created := fmt.Sprintf("created=%d", time.Now().Add(-2*SignatureMaxAge).Unix())
+			input := req.Header.Get(SignatureInputHeader)
+			start := strings.Index(input, "created=")
+			end := start + strings.Index(input[start:], ";")
+			req.Header.Set(SignatureInputHeader, input[:start]+created+input[end:])
+		}, false},
+	}
+
+	for _, test := range tests {
+		req := newSignedRequest(t, privateKey, test.body, test.token)
+
+		if test.tamper != nil {
+			test.tamper(req)
+		}
+
+		keyID, err := VerifyRequest(req, "vertex.example", keyFunc)
+
+		if test.valid && (err != nil || keyID != "sender.example") {
+			t.Errorf("%s: expected valid signature, got %q, %v", test.name, keyID, err)
+		}
+
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected verification to fail", test.name)
+		}
+	}
+}
+
+func TestVerifyRequestChecksAuthority(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFunc := func(string) (ed25519.PublicKey, error) {
+		return publicKey, nil
+	}
+
+	_, err = VerifyRequest(newSignedRequest(t, privateKey, `{}`, "token"), "Vertex.Example", keyFunc)
+
+	if err != nil {
+		t.Fatalf("expected the authority to match case-insensitively, got %v", err)
+	}
+
+	_, err = VerifyRequest(newSignedRequest(t, privateKey, `{}`, "token"), "other.example", keyFunc)
+
+	if err == nil {
+		t.Fatal("expected a request signed for another vertex to be rejected")
+	}
+}
+
+func TestVerifyRequestKeepsBody(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := newSignedRequest(t, privateKey, `{"content":"hello"}`, "token")
+
+	_, err = VerifyRequest(req, "vertex.example", func(string) (ed25519.PublicKey, error) {
+		return publicKey, nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+
+	_, err = body.ReadFrom(req.Body)
+
+	if err != nil || body.String() != `{"content":"hello"}` {
+		t.Fatalf("expected body to be readable after verification, got %q, %v", body.String(), err)
+	}
+}
@@ -0,0 +1,73 @@
+package federation
+
+import (
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+type Config struct {
+	Vertex           string
+	SigningKey       ed25519.PrivateKey
+	Scheme           string
+	AllowInsecure    bool
+	InsecureVertices []string
+	CAFile           string
+	ClientCertFile   string
+	ClientKeyFile    string
+	Timeout          time.Duration
+	PeerTimeouts     map[string]time.Duration
+}
+
+func NewTransport(config *Config) (*http.Transport, error) {
+	switch config.Scheme {
+	case "https":
+	case "http":
+		if !config.AllowInsecure {
+			return nil, fmt.Errorf("plain http federation with every peer must be explicitly allowed")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported federation scheme %s", config.Scheme)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		data, err := os.ReadFile(config.CAFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read federation CA bundle: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in federation CA bundle %s", config.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load federation client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
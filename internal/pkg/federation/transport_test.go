@@ -0,0 +1,63 @@
+package federation
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewTransportScheme(t *testing.T) {
+	tests := []struct {
+		name          string
+		scheme        string
+		allowInsecure bool
+		valid         bool
+	}{
+		{"https", "https", false, true},
+		{"http without opt-in", "http", false, false},
+		{"http with opt-in", "http", true, true},
+		{"unsupported", "ftp", true, false},
+		{"empty", "", false, false},
+	}
+
+	for _, test := range tests {
+		_, err := NewTransport(&Config{Scheme: test.scheme, AllowInsecure: test.allowInsecure})
+
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got error %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		url     string
+		allowed bool
+		scheme  string
+	}{
+		{"https peer", &Config{Scheme: "https"}, "https://remote.example/api", true, "https"},
+		{"http peer", &Config{Scheme: "https"}, "http://remote.example/api", false, "http"},
+		{"insecure peer", &Config{Scheme: "https", InsecureVertices: []string{"local.test"}}, "https://local.test/api", true, "http"},
+		{"http scheme without opt-in", &Config{Scheme: "http"}, "http://remote.example/api", false, "http"},
+		{"http scheme with opt-in", &Config{Scheme: "http", AllowInsecure: true}, "http://remote.example/api", true, "http"},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, test.url, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = NewClient(test.config, http.DefaultClient).allow(req)
+
+		if (err == nil) != test.allowed {
+			t.Errorf("%s: expected allowed %v, got error %v", test.name, test.allowed, err)
+		}
+
+		if req.URL.Scheme != test.scheme {
+			t.Errorf("%s: expected scheme %s, got %s", test.name, test.scheme, req.URL.Scheme)
+		}
+	}
+}
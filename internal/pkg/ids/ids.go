@@ -0,0 +1,18 @@
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+func Generate() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -3,6 +3,10 @@ package keys
 import (
 	"crypto/ed25519"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
 )
 
 func GenerateED25519KeyPair() (ed25519.PublicKey, ed25519.PrivateKey, error) {
@@ -32,3 +36,56 @@ func ConvertED25519PublicKeyFromString(publicKeyString string) (ed25519.PublicKe
 	}
 	return publicKeyBytes, nil
 }
+
+func SignED25519(privateKey ed25519.PrivateKey, data []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, data))
+}
+
+func VerifyED25519(publicKey ed25519.PublicKey, data []byte, signature string) bool {
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+
+	return ed25519.Verify(publicKey, data, signatureBytes)
+}
+
+func LoadOrGenerateED25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+
+	if err == nil {
+		privateKey, err := ConvertED25519PrivateKeyFromString(strings.TrimSpace(string(data)))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(privateKey) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("invalid ed25519 private key in %s", path)
+		}
+
+		return privateKey, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	_, privateKey, err := GenerateED25519KeyPair()
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(path, []byte(ConvertED25519PrivateKeyToString(privateKey)), 0600)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return privateKey, nil
+}
@@ -0,0 +1,18 @@
+package keys
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+)
+
+func ConvertX25519PublicKeyToString(publicKey *ecdh.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(publicKey.Bytes())
+}
+
+func ConvertX25519PublicKeyFromString(publicKeyString string) (*ecdh.PublicKey, error) {
+	publicKeyBytes, err := base64.StdEncoding.DecodeString(publicKeyString)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPublicKey(publicKeyBytes)
+}
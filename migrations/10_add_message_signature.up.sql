@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN signature TEXT NOT NULL DEFAULT '';
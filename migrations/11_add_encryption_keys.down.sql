@@ -0,0 +1,3 @@
+ALTER TABLE messages DROP COLUMN encryption_public_key;
+ALTER TABLE messages DROP COLUMN encryption;
+ALTER TABLE actors DROP COLUMN encryption_public_key;
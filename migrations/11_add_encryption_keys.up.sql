@@ -0,0 +1,3 @@
+ALTER TABLE actors ADD COLUMN encryption_public_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE messages ADD COLUMN encryption TEXT NOT NULL DEFAULT '';
+ALTER TABLE messages ADD COLUMN encryption_public_key TEXT NOT NULL DEFAULT '';
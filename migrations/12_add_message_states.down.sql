@@ -0,0 +1,4 @@
+ALTER TABLE deliveries DROP COLUMN read_at;
+ALTER TABLE inboxes DROP COLUMN read_receipts;
+DROP INDEX messages_state_index;
+ALTER TABLE messages DROP COLUMN state;
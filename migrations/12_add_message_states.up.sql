@@ -0,0 +1,5 @@
+ALTER TABLE messages ADD COLUMN state TEXT NOT NULL DEFAULT '';
+UPDATE messages SET state = 'unread' WHERE box_type = 'inbox';
+CREATE INDEX messages_state_index ON messages (box_type, box_identifier, node_identifier, state);
+ALTER TABLE inboxes ADD COLUMN read_receipts INT NOT NULL DEFAULT 0;
+ALTER TABLE deliveries ADD COLUMN read_at INT NOT NULL DEFAULT 0;
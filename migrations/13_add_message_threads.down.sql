@@ -0,0 +1,3 @@
+DROP INDEX messages_thread_index;
+ALTER TABLE messages DROP COLUMN in_reply_to;
+ALTER TABLE messages DROP COLUMN thread_identifier;
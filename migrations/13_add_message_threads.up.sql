@@ -0,0 +1,4 @@
+ALTER TABLE messages ADD COLUMN thread_identifier TEXT NOT NULL DEFAULT '';
+ALTER TABLE messages ADD COLUMN in_reply_to TEXT NOT NULL DEFAULT '';
+UPDATE messages SET thread_identifier = identifier WHERE thread_identifier = '';
+CREATE INDEX messages_thread_index ON messages (thread_identifier, actor_address, node_identifier, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE messages DROP COLUMN attachments;
+DROP INDEX blob_references_box_index;
+DROP TABLE blob_references;
+DROP TABLE blob_uploads;
+DROP TABLE blobs;
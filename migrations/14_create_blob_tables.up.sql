@@ -0,0 +1,34 @@
+CREATE TABLE blobs
+(
+    hash         TEXT PRIMARY KEY,
+    size         INT  NOT NULL,
+    content_type TEXT NOT NULL,
+    stored       INT  NOT NULL,
+    created_at   INT  NOT NULL,
+    updated_at   INT  NOT NULL
+);
+
+CREATE TABLE blob_uploads
+(
+    hash            TEXT NOT NULL,
+    actor_address   TEXT NOT NULL,
+    node_identifier TEXT NOT NULL,
+    created_at      INT  NOT NULL,
+    PRIMARY KEY (hash, actor_address, node_identifier)
+);
+
+CREATE TABLE blob_references
+(
+    hash               TEXT NOT NULL,
+    message_identifier TEXT NOT NULL,
+    box_type           TEXT NOT NULL,
+    box_identifier     TEXT NOT NULL,
+    node_identifier    TEXT NOT NULL,
+    actor_address      TEXT NOT NULL,
+    created_at         INT  NOT NULL,
+    PRIMARY KEY (hash, message_identifier, box_type, box_identifier, node_identifier)
+);
+
+CREATE INDEX blob_references_box_index ON blob_references (box_type, box_identifier, node_identifier);
+
+ALTER TABLE messages ADD COLUMN attachments TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+ALTER TABLE messages DROP COLUMN labels;
+ALTER TABLE messages DROP COLUMN headers;
+DROP INDEX inbox_rules_actor_index;
+DROP TABLE inbox_rules;
@@ -0,0 +1,21 @@
+CREATE TABLE inbox_rules
+(
+    identifier              TEXT PRIMARY KEY,
+    actor_address           TEXT NOT NULL,
+    node_identifier         TEXT NOT NULL,
+    inbox_identifier        TEXT NOT NULL,
+    position                INT  NOT NULL,
+    field                   TEXT NOT NULL,
+    header                  TEXT NOT NULL,
+    pattern                 TEXT NOT NULL,
+    action                  TEXT NOT NULL,
+    target_inbox_identifier TEXT NOT NULL,
+    label                   TEXT NOT NULL,
+    created_at              INT  NOT NULL,
+    updated_at              INT  NOT NULL
+);
+
+CREATE INDEX inbox_rules_actor_index ON inbox_rules (actor_address, node_identifier, position);
+
+ALTER TABLE messages ADD COLUMN headers TEXT NOT NULL DEFAULT '';
+ALTER TABLE messages ADD COLUMN labels TEXT NOT NULL DEFAULT '';
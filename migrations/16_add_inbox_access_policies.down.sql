@@ -0,0 +1,2 @@
+DROP TABLE inbox_access_entries;
+ALTER TABLE inboxes DROP COLUMN policy;
@@ -0,0 +1,12 @@
+ALTER TABLE inboxes ADD COLUMN policy TEXT NOT NULL DEFAULT 'open';
+
+CREATE TABLE inbox_access_entries
+(
+    inbox_identifier TEXT NOT NULL,
+    node_identifier  TEXT NOT NULL,
+    actor_address    TEXT NOT NULL,
+    list             TEXT NOT NULL,
+    entry            TEXT NOT NULL,
+    created_at       INT  NOT NULL,
+    PRIMARY KEY (inbox_identifier, node_identifier, list, entry)
+);
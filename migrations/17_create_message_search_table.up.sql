@@ -0,0 +1,37 @@
+CREATE VIRTUAL TABLE message_search USING fts5
+(
+    content,
+    sender_address,
+    headers,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO message_search (rowid, content, sender_address, headers)
+SELECT rowid, CASE WHEN encryption = '' THEN content ELSE '' END, sender_address, headers
+FROM messages;
+
+CREATE TRIGGER messages_search_insert
+    AFTER INSERT
+    ON messages
+BEGIN
+    INSERT INTO message_search (rowid, content, sender_address, headers)
+    VALUES (new.rowid, CASE WHEN new.encryption = '' THEN new.content ELSE '' END, new.sender_address, new.headers);
+END;
+
+CREATE TRIGGER messages_search_delete
+    AFTER DELETE
+    ON messages
+BEGIN
+    DELETE FROM message_search WHERE rowid = old.rowid;
+END;
+
+CREATE TRIGGER messages_search_update
+    AFTER UPDATE OF content, encryption, sender_address, headers
+    ON messages
+BEGIN
+    UPDATE message_search
+    SET content        = CASE WHEN new.encryption = '' THEN new.content ELSE '' END,
+        sender_address = new.sender_address,
+        headers        = new.headers
+    WHERE rowid = old.rowid;
+END;
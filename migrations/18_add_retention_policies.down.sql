@@ -0,0 +1,5 @@
+DROP INDEX blobs_created_at_index;
+ALTER TABLE inboxes DROP COLUMN retention_max_count;
+ALTER TABLE inboxes DROP COLUMN retention_max_age;
+ALTER TABLE nodes DROP COLUMN retention_max_count;
+ALTER TABLE nodes DROP COLUMN retention_max_age;
@@ -0,0 +1,5 @@
+ALTER TABLE nodes ADD COLUMN retention_max_age INT NOT NULL DEFAULT 0;
+ALTER TABLE nodes ADD COLUMN retention_max_count INT NOT NULL DEFAULT 0;
+ALTER TABLE inboxes ADD COLUMN retention_max_age INT NOT NULL DEFAULT 0;
+ALTER TABLE inboxes ADD COLUMN retention_max_count INT NOT NULL DEFAULT 0;
+CREATE INDEX blobs_created_at_index ON blobs (created_at);
@@ -0,0 +1,8 @@
+DROP INDEX deliveries_status_cursor_index;
+DROP INDEX messages_actor_cursor_index;
+DROP INDEX messages_thread_cursor_index;
+DROP INDEX messages_box_cursor_index;
+DROP INDEX outboxes_actor_index;
+DROP INDEX inboxes_actor_index;
+DROP INDEX admins_created_at_index;
+DROP INDEX nodes_created_at_index;
@@ -0,0 +1,8 @@
+CREATE INDEX nodes_created_at_index ON nodes (created_at, identifier);
+CREATE INDEX admins_created_at_index ON admins (created_at, identifier);
+CREATE INDEX inboxes_actor_index ON inboxes (actor_address, node_identifier, created_at, identifier);
+CREATE INDEX outboxes_actor_index ON outboxes (actor_address, node_identifier, created_at, identifier);
+CREATE INDEX messages_box_cursor_index ON messages (box_type, box_identifier, node_identifier, created_at, identifier);
+CREATE INDEX messages_thread_cursor_index ON messages (thread_identifier, actor_address, node_identifier, created_at, identifier);
+CREATE INDEX messages_actor_cursor_index ON messages (actor_address, node_identifier, created_at, identifier);
+CREATE INDEX deliveries_status_cursor_index ON deliveries (status, created_at, identifier);
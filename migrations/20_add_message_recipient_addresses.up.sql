@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN recipient_addresses TEXT NOT NULL DEFAULT '';
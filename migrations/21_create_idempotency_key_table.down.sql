@@ -0,0 +1,2 @@
+DROP INDEX idempotency_keys_expires_at_index;
+DROP TABLE idempotency_keys;
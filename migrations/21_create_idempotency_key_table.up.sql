@@ -0,0 +1,15 @@
+CREATE TABLE idempotency_keys
+(
+    principal    TEXT NOT NULL,
+    key          TEXT NOT NULL,
+    fingerprint  TEXT NOT NULL,
+    status_code  INT  NOT NULL,
+    content_type TEXT NOT NULL,
+    body         BLOB NOT NULL,
+    completed    INT  NOT NULL,
+    created_at   INT  NOT NULL,
+    expires_at   INT  NOT NULL,
+    PRIMARY KEY (principal, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_index ON idempotency_keys (expires_at);
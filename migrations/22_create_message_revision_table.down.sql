@@ -0,0 +1,5 @@
+DROP TRIGGER messages_revisions_delete;
+DROP TABLE message_revisions;
+ALTER TABLE deliveries DROP COLUMN revision;
+ALTER TABLE messages DROP COLUMN retracted_at;
+ALTER TABLE messages DROP COLUMN revision;
@@ -0,0 +1,32 @@
+ALTER TABLE messages ADD COLUMN revision INT NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN retracted_at INT NOT NULL DEFAULT 0;
+ALTER TABLE deliveries ADD COLUMN revision INT NOT NULL DEFAULT 0;
+
+CREATE TABLE message_revisions
+(
+    message_identifier    TEXT NOT NULL,
+    box_type              TEXT NOT NULL,
+    box_identifier        TEXT NOT NULL,
+    node_identifier       TEXT NOT NULL,
+    revision              INT  NOT NULL,
+    content_type          TEXT NOT NULL,
+    content               TEXT NOT NULL,
+    encryption            TEXT NOT NULL,
+    encryption_public_key TEXT NOT NULL,
+    attachments           TEXT NOT NULL,
+    headers               TEXT NOT NULL,
+    signature             TEXT NOT NULL,
+    replaced_at           INT  NOT NULL,
+    PRIMARY KEY (message_identifier, box_type, box_identifier, node_identifier, revision)
+);
+
+CREATE TRIGGER messages_revisions_delete
+    AFTER DELETE
+    ON messages
+BEGIN
+    DELETE FROM message_revisions
+    WHERE message_identifier = old.identifier
+      AND box_type = old.box_type
+      AND box_identifier = old.box_identifier
+      AND node_identifier = old.node_identifier;
+END;
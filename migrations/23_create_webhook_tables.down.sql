@@ -0,0 +1,7 @@
+DROP INDEX webhook_deliveries_created_at_index;
+DROP INDEX webhook_deliveries_webhook_index;
+DROP INDEX webhook_deliveries_status_index;
+DROP TABLE webhook_deliveries;
+
+DROP INDEX webhooks_actor_index;
+DROP TABLE webhooks;
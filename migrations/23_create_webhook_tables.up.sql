@@ -0,0 +1,34 @@
+CREATE TABLE webhooks
+(
+    identifier       TEXT PRIMARY KEY,
+    actor_address    TEXT NOT NULL,
+    node_identifier  TEXT NOT NULL,
+    inbox_identifier TEXT NOT NULL,
+    url              TEXT NOT NULL,
+    events           TEXT NOT NULL,
+    secret           TEXT NOT NULL,
+    enabled          INT  NOT NULL,
+    created_at       INT  NOT NULL,
+    updated_at       INT  NOT NULL
+);
+
+CREATE INDEX webhooks_actor_index ON webhooks (actor_address, node_identifier, created_at, identifier);
+
+CREATE TABLE webhook_deliveries
+(
+    identifier         TEXT PRIMARY KEY,
+    webhook_identifier TEXT NOT NULL,
+    event              TEXT NOT NULL,
+    payload            TEXT NOT NULL,
+    status             TEXT NOT NULL,
+    attempts           INT  NOT NULL,
+    status_code        INT  NOT NULL,
+    last_error         TEXT NOT NULL,
+    next_attempt_at    INT  NOT NULL,
+    created_at         INT  NOT NULL,
+    updated_at         INT  NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_status_index ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX webhook_deliveries_webhook_index ON webhook_deliveries (webhook_identifier, created_at, identifier);
+CREATE INDEX webhook_deliveries_created_at_index ON webhook_deliveries (created_at);
@@ -0,0 +1,2 @@
+DROP TABLE federation_policy_entries;
+DROP TABLE federation_policies;
@@ -0,0 +1,16 @@
+CREATE TABLE federation_policies
+(
+    vertex     TEXT PRIMARY KEY,
+    mode       TEXT NOT NULL,
+    updated_by TEXT NOT NULL,
+    updated_at INT  NOT NULL
+);
+
+CREATE TABLE federation_policy_entries
+(
+    list       TEXT NOT NULL,
+    entry      TEXT NOT NULL,
+    creator    TEXT NOT NULL,
+    created_at INT  NOT NULL,
+    PRIMARY KEY (list, entry)
+);
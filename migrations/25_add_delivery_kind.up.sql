@@ -0,0 +1 @@
+ALTER TABLE deliveries ADD COLUMN kind TEXT NOT NULL DEFAULT 'message';
@@ -0,0 +1 @@
+ALTER TABLE blob_references DROP COLUMN verified;
@@ -0,0 +1 @@
+ALTER TABLE blob_references ADD COLUMN verified INT NOT NULL DEFAULT 1;
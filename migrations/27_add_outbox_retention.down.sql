@@ -0,0 +1,2 @@
+ALTER TABLE outboxes DROP COLUMN retention_max_count;
+ALTER TABLE outboxes DROP COLUMN retention_max_age;
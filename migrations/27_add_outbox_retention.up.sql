@@ -0,0 +1,2 @@
+ALTER TABLE outboxes ADD COLUMN retention_max_age INT NOT NULL DEFAULT 0;
+ALTER TABLE outboxes ADD COLUMN retention_max_count INT NOT NULL DEFAULT 0;
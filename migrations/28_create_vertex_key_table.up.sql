@@ -0,0 +1,6 @@
+CREATE TABLE vertex_keys
+(
+    vertex     TEXT PRIMARY KEY,
+    public_key TEXT NOT NULL,
+    pinned_at  INT  NOT NULL
+);
@@ -0,0 +1,18 @@
+UPDATE OR IGNORE inbox_access_entries
+SET entry = (CASE
+                 WHEN lower(substr(entry, 1, instr(entry || '/', '/') - 1)) LIKE '%:443'
+                     THEN lower(substr(entry, 1, instr(entry || '/', '/') - 5))
+                 WHEN lower(substr(entry, 1, instr(entry || '/', '/') - 1)) LIKE '%:80'
+                     THEN lower(substr(entry, 1, instr(entry || '/', '/') - 4))
+                 ELSE lower(substr(entry, 1, instr(entry || '/', '/') - 1))
+    END) || substr(entry, instr(entry || '/', '/'));
+
+DELETE
+FROM inbox_access_entries
+WHERE entry != (CASE
+                    WHEN lower(substr(entry, 1, instr(entry || '/', '/') - 1)) LIKE '%:443'
+                        THEN lower(substr(entry, 1, instr(entry || '/', '/') - 5))
+                    WHEN lower(substr(entry, 1, instr(entry || '/', '/') - 1)) LIKE '%:80'
+                        THEN lower(substr(entry, 1, instr(entry || '/', '/') - 4))
+                    ELSE lower(substr(entry, 1, instr(entry || '/', '/') - 1))
+    END) || substr(entry, instr(entry || '/', '/'));
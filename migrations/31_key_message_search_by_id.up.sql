@@ -0,0 +1,87 @@
+DROP TRIGGER IF EXISTS messages_search_update;
+DROP TRIGGER IF EXISTS messages_search_delete;
+DROP TRIGGER IF EXISTS messages_search_insert;
+DROP TABLE IF EXISTS message_search;
+
+CREATE TABLE message_search_keys
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    identifier      TEXT NOT NULL,
+    box_type        TEXT NOT NULL,
+    box_identifier  TEXT NOT NULL,
+    node_identifier TEXT NOT NULL,
+    UNIQUE (identifier, box_type, box_identifier, node_identifier)
+);
+
+CREATE VIRTUAL TABLE message_search USING fts5
+(
+    content,
+    sender_address,
+    headers,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO message_search_keys (identifier, box_type, box_identifier, node_identifier)
+SELECT identifier, box_type, box_identifier, node_identifier
+FROM messages;
+
+INSERT INTO message_search (rowid, content, sender_address, headers)
+SELECT k.id, CASE WHEN m.encryption = '' THEN m.content ELSE '' END, m.sender_address, m.headers
+FROM messages m
+         JOIN message_search_keys k
+              ON k.identifier = m.identifier AND k.box_type = m.box_type AND k.box_identifier = m.box_identifier AND
+                 k.node_identifier = m.node_identifier;
+
+CREATE TRIGGER messages_search_insert
+    AFTER INSERT
+    ON messages
+BEGIN
+    INSERT INTO message_search_keys (identifier, box_type, box_identifier, node_identifier)
+    VALUES (new.identifier, new.box_type, new.box_identifier, new.node_identifier);
+
+    INSERT INTO message_search (rowid, content, sender_address, headers)
+    VALUES ((SELECT id
+             FROM message_search_keys
+             WHERE identifier = new.identifier
+               AND box_type = new.box_type
+               AND box_identifier = new.box_identifier
+               AND node_identifier = new.node_identifier),
+            CASE WHEN new.encryption = '' THEN new.content ELSE '' END, new.sender_address, new.headers);
+END;
+
+CREATE TRIGGER messages_search_delete
+    AFTER DELETE
+    ON messages
+BEGIN
+    DELETE
+    FROM message_search
+    WHERE rowid = (SELECT id
+                   FROM message_search_keys
+                   WHERE identifier = old.identifier
+                     AND box_type = old.box_type
+                     AND box_identifier = old.box_identifier
+                     AND node_identifier = old.node_identifier);
+
+    DELETE
+    FROM message_search_keys
+    WHERE identifier = old.identifier
+      AND box_type = old.box_type
+      AND box_identifier = old.box_identifier
+      AND node_identifier = old.node_identifier;
+END;
+
+CREATE TRIGGER messages_search_update
+    AFTER UPDATE OF content, encryption, sender_address, headers
+    ON messages
+BEGIN
+    UPDATE message_search
+    SET content        = CASE WHEN new.encryption = '' THEN new.content ELSE '' END,
+        sender_address = new.sender_address,
+        headers        = new.headers
+    WHERE rowid = (SELECT id
+                   FROM message_search_keys
+                   WHERE identifier = old.identifier
+                     AND box_type = old.box_type
+                     AND box_identifier = old.box_identifier
+                     AND node_identifier = old.node_identifier);
+END;
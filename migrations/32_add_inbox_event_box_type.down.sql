@@ -0,0 +1,7 @@
+DROP INDEX inbox_events_inbox_index;
+
+DELETE FROM inbox_events WHERE box_type != 'inbox';
+
+ALTER TABLE inbox_events DROP COLUMN box_type;
+
+CREATE INDEX inbox_events_inbox_index ON inbox_events (inbox_identifier, node_identifier, sequence);
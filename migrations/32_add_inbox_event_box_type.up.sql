@@ -0,0 +1,5 @@
+ALTER TABLE inbox_events ADD COLUMN box_type TEXT NOT NULL DEFAULT 'inbox';
+
+DROP INDEX inbox_events_inbox_index;
+
+CREATE INDEX inbox_events_inbox_index ON inbox_events (box_type, inbox_identifier, node_identifier, sequence);
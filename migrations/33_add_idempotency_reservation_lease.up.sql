@@ -0,0 +1 @@
+ALTER TABLE idempotency_keys ADD COLUMN reserved_at INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX messages_box_index;
+DROP TABLE messages;
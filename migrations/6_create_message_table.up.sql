@@ -0,0 +1,17 @@
+CREATE TABLE messages
+(
+    identifier        TEXT NOT NULL,
+    box_type          TEXT NOT NULL,
+    box_identifier    TEXT NOT NULL,
+    node_identifier   TEXT NOT NULL,
+    actor_address     TEXT NOT NULL,
+    sender_address    TEXT NOT NULL,
+    recipient_address TEXT NOT NULL,
+    content_type      TEXT NOT NULL,
+    content           TEXT NOT NULL,
+    created_at        INT  NOT NULL,
+    updated_at        INT  NOT NULL,
+    PRIMARY KEY (identifier, box_type, box_identifier, node_identifier)
+);
+
+CREATE INDEX messages_box_index ON messages (box_type, box_identifier, node_identifier, created_at);
@@ -0,0 +1,3 @@
+DROP TABLE delivery_backoffs;
+DROP INDEX deliveries_status_index;
+DROP TABLE deliveries;
@@ -0,0 +1,26 @@
+CREATE TABLE deliveries
+(
+    identifier         TEXT PRIMARY KEY,
+    message_identifier TEXT NOT NULL,
+    outbox_identifier  TEXT NOT NULL,
+    node_identifier    TEXT NOT NULL,
+    sender_address     TEXT NOT NULL,
+    recipient_address  TEXT NOT NULL,
+    destination_vertex TEXT NOT NULL,
+    status             TEXT NOT NULL,
+    attempts           INT  NOT NULL,
+    last_error         TEXT NOT NULL,
+    next_attempt_at    INT  NOT NULL,
+    created_at         INT  NOT NULL,
+    updated_at         INT  NOT NULL
+);
+
+CREATE INDEX deliveries_status_index ON deliveries (status, next_attempt_at);
+
+CREATE TABLE delivery_backoffs
+(
+    vertex          TEXT PRIMARY KEY,
+    failures        INT NOT NULL,
+    next_attempt_at INT NOT NULL,
+    updated_at      INT NOT NULL
+);
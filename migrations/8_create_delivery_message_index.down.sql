@@ -0,0 +1 @@
+DROP INDEX deliveries_message_index;
@@ -0,0 +1 @@
+CREATE INDEX deliveries_message_index ON deliveries (message_identifier, outbox_identifier, node_identifier);
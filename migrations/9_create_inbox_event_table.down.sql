@@ -0,0 +1,2 @@
+DROP INDEX inbox_events_inbox_index;
+DROP TABLE inbox_events;
@@ -0,0 +1,12 @@
+CREATE TABLE inbox_events
+(
+    sequence           INTEGER PRIMARY KEY AUTOINCREMENT,
+    inbox_identifier   TEXT NOT NULL,
+    node_identifier    TEXT NOT NULL,
+    type               TEXT NOT NULL,
+    message_identifier TEXT NOT NULL,
+    data               TEXT NOT NULL,
+    created_at         INT  NOT NULL
+);
+
+CREATE INDEX inbox_events_inbox_index ON inbox_events (inbox_identifier, node_identifier, sequence);